@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"github.com/tadnir/goop/package_parser"
 	"github.com/tadnir/goop/utils"
+	"go/ast"
+	"go/token"
+	"go/types"
 	"maps"
 	"slices"
 	"strings"
@@ -17,6 +20,11 @@ func NewClassesContainer() *ClassesContainer {
 	return &ClassesContainer{classes: make(map[string]*Class)}
 }
 
+func (c *ClassesContainer) HasClass(name string) bool {
+	_, knownClass := c.classes[name]
+	return knownClass
+}
+
 func (c *ClassesContainer) GetClass(name string) *Class {
 	if _, knownClass := c.classes[name]; !knownClass {
 		c.classes[name] = &Class{name: name, overrides: []*Override{}}
@@ -24,22 +32,16 @@ func (c *ClassesContainer) GetClass(name string) *Class {
 	return c.classes[name]
 }
 
+// AddClass registers a class declared in another package under the name it's referred to by, e.g. pkg.Base.
+func (c *ClassesContainer) AddClass(name string, class *Class) {
+	c.classes[name] = class
+}
+
+// GetClassesSorted returns the classes ordered so that every class comes after all of its ancestors.
 func (c *ClassesContainer) GetClassesSorted() []*Class {
 	return slices.SortedStableFunc(maps.Values(c.classes), func(class *Class, class2 *Class) int {
-		if class.super == nil && class2.super != nil {
-			return -1
-		}
-
-		if class.super != nil && class2.super == nil {
-			return 1
-		}
-
-		if class.super == class2 {
-			return 1
-		}
-
-		if class2.super == class {
-			return -1
+		if depth, depth2 := class.Depth(), class2.Depth(); depth != depth2 {
+			return depth - depth2
 		}
 
 		return strings.Compare(class.name, class2.name)
@@ -57,32 +59,57 @@ func (c *ClassesContainer) String() string {
 }
 
 type Class struct {
-	name      string
-	super     *Class
-	vtable    *VTable
-	overrides []*Override
+	name       string
+	typeParams []*package_parser.TypeParam
+	super      *Class
+	// superTypeArgs instantiate the type parameters of a generic super, e.g. T in Base[T]
+	superTypeArgs      []string
+	vtables            []*VTable
+	overrides          []*Override
+	abstractInterfaces []string
+	constructor        *package_parser.Function
+	// implements are the interfaces the class is asserted to satisfy
+	implements []string
+	// interfaces are the interfaces generated with all the exported virtual methods of the class
+	interfaces []string
+	// pkg is the package declaring the class when it's inherited from another package, nil otherwise
+	pkg *ClassPackage
+	// declaration is the struct declaring the class
+	declaration *package_parser.StructDeclaration
+	// extensible classes are marked by a //goop:extensible directive, and get the hooks letting classes of other
+	// packages inherit them
+	extensible bool
 }
 
 func (c *Class) String() string {
 	var sb strings.Builder
 
+	kind := "Class"
+	if c.IsAbstract() {
+		kind = "Abstract Class"
+	}
+
 	if c.super == nil {
-		sb.WriteString(fmt.Sprintf("Class %s {\n", c.name))
+		sb.WriteString(fmt.Sprintf("%s %s%s {\n", kind, c.name, package_parser.TypeParamsString(c.typeParams)))
 	} else {
-		sb.WriteString(fmt.Sprintf("Class %s : %s {\n", c.name, c.super.name))
+		sb.WriteString(fmt.Sprintf("%s %s%s : %s {\n", kind, c.name, package_parser.TypeParamsString(c.typeParams), c.SuperTypeName()))
 	}
 
-	if c.vtable != nil {
-		sb.WriteString(fmt.Sprintf("VTable(%s):\n", c.vtable.name))
-		for _, virt := range c.vtable.functions {
-			sb.WriteString(fmt.Sprintf("\t%s\n", virt.signature))
+	for _, vtable := range c.vtables {
+		sb.WriteString(fmt.Sprintf("VTable(%s):\n", vtable.name))
+		for _, virt := range vtable.functions {
+			if virt.abstract {
+				sb.WriteString(fmt.Sprintf("\tabstract %s %s\n", virt.name, virt.signature))
+			} else {
+				sb.WriteString(fmt.Sprintf("\t%s %s\n", virt.name, virt.signature))
+			}
 		}
 	}
 
 	for _, override := range c.overrides {
 		sb.WriteString(fmt.Sprintf("Overrides(%s):\n", override.overriddenVtable.name))
-		for _, override := range override.overriddenVtable.functions {
-			sb.WriteString(fmt.Sprintf("\t%s\n", override.signature))
+		for _, override := range override.functions {
+			sb.WriteString(fmt.Sprintf("\t%s %s\n", override.name, override.signature))
 		}
 	}
 
@@ -91,71 +118,425 @@ func (c *Class) String() string {
 	return sb.String()
 }
 
-func (c *Class) ChooseVTable(method *package_parser.Function) *VTable {
-	for _, override := range c.overrides {
-		if override.overriddenVtable.HasMethod(MethodVirtualName(method)) {
-			return override.overriddenVtable
+// ChooseVTable returns the vtable holding the virtual method: the vtable declaring it when it overrides a virtual
+// method, otherwise the vtable of the class named by the method's //goop:vtable directive, or the first vtable of
+// the class.
+func (c *Class) ChooseVTable(method *package_parser.Function) (*VTable, error) {
+	requested := VTableDirective(method)
+	if owner, vtable, function := c.FindVirtual(MethodVirtualName(method)); vtable != nil {
+		if requested != "" && requested != vtable.name {
+			return nil, package_parser.Errorf(method.Position, "%s of %s is bound to %s, but %s declares it in %s", method.Name, c.name, requested, owner.name, vtable.name)
+		}
+
+		if !MatchesSignature(function.method, method) && !c.IsGeneric() && !owner.IsGeneric() {
+			return nil, package_parser.Errorf(method.Position, "%s of %s has the signature %s, but %s declares %s with %s", method.Name, c.name,
+				method.ResolvedSignature(), owner.name, function.name, function.method.ResolvedSignature())
 		}
+
+		return vtable, nil
 	}
 
-	if c.super != nil {
-		superVTable := c.super.ChooseVTable(method)
-		if superVTable != nil {
-			return superVTable
+	if requested != "" {
+		if vtable := c.GetVTable(requested); vtable != nil {
+			return vtable, nil
 		}
+
+		return nil, package_parser.Errorf(method.Position, "%s of %s is bound to %s, which isn't a vtable of %s", method.Name, c.name, requested, c.name)
 	}
 
-	// May be nil
-	return c.vtable
+	if c.HasVTable() {
+		return c.vtables[0], nil
+	}
+
+	return nil, package_parser.Errorf(method.Position, "can't find vtable for %s in %s", method.Name, c.name)
 }
 
 func (c *Class) HasVTable() bool {
-	return c.vtable != nil
+	return len(c.vtables) > 0
+}
+
+// OwnsVTable reports whether the vtable was introduced by the class itself.
+func (c *Class) OwnsVTable(vtable *VTable) bool {
+	return slices.Contains(c.vtables, vtable)
+}
+
+func (c *Class) GetVTable(name string) *VTable {
+	for _, vtable := range c.vtables {
+		if vtable.name == name {
+			return vtable
+		}
+	}
+
+	return nil
+}
+
+// RootVTable returns the vtable of the topmost class in the hierarchy having one, it holds the sync.Once
+// initializing all the vtables of the object, and its runtime type information.
+func (c *Class) RootVTable() *VTable {
+	if owner := c.RootVTableOwner(); owner != nil {
+		return owner.vtables[0]
+	}
+
+	return nil
+}
+
+// RootVTableOwner returns the class introducing the root vtable of the class.
+func (c *Class) RootVTableOwner() *Class {
+	var owner *Class
+	for class := c; class != nil; class = class.super {
+		if class.HasVTable() {
+			owner = class
+		}
+	}
+
+	return owner
+}
+
+// IsExternal reports whether the class is declared in another package than the one being generated.
+func (c *Class) IsExternal() bool {
+	return c.pkg != nil
+}
+
+// QualifiedName is the name of the class type as referred to by the generated package.
+func (c *Class) QualifiedName() string {
+	if c.IsExternal() {
+		return c.pkg.name + "." + c.name
+	}
+
+	return c.name
+}
+
+// Position is where the struct of the class is declared, the zero position when it wasn't parsed, e.g. for supers
+// without goop tags.
+func (c *Class) Position() token.Position {
+	if c.declaration == nil {
+		return token.Position{}
+	}
+
+	return c.declaration.Position
+}
+
+// IsExtensible reports whether classes of other packages may inherit the class.
+func (c *Class) IsExtensible() bool {
+	return c.extensible
+}
+
+// IsGeneric reports whether the class has type parameters.
+func (c *Class) IsGeneric() bool {
+	return len(c.typeParams) > 0
+}
+
+// TypeName is the class type instantiated with its own type parameters, e.g. Repo[T], as used by its receivers.
+func (c *Class) TypeName() string {
+	return c.name + package_parser.TypeArgsString(c.typeParams)
+}
+
+// SuperTypeName is the super type embedded by the class, e.g. Base[T].
+func (c *Class) SuperTypeName() string {
+	if len(c.superTypeArgs) == 0 {
+		return c.super.QualifiedName()
+	}
+
+	return fmt.Sprintf("%s[%s]", c.super.QualifiedName(), strings.Join(c.superTypeArgs, ", "))
+}
+
+// Instantiate rewrites a type expression using the type parameters of ancestor in terms of the type parameters of
+// the class, following the instantiations of the supers between them.
+func (c *Class) Instantiate(ancestor *Class, typeExpr string) string {
+	if c == ancestor || c.super == nil {
+		return typeExpr
+	}
+
+	return SubstituteTypeParams(c.super.Instantiate(ancestor, typeExpr), c.super.typeParams, c.superTypeArgs)
+}
+
+// InstantiateFunction returns a copy of a method of ancestor whose types are instantiated for the class.
+func (c *Class) InstantiateFunction(ancestor *Class, method *package_parser.Function) *package_parser.Function {
+	if c == ancestor {
+		return method
+	}
+
+	return method.MapTypes(func(typeExpr string) string {
+		return c.Instantiate(ancestor, typeExpr)
+	})
+}
+
+// DescriptorName is the name of the variable holding the runtime description of the class.
+func (c *Class) DescriptorName() string {
+	if c.IsExternal() {
+		return c.pkg.name + "." + c.name + "Class"
+	}
+
+	return c.name + "Class"
+}
+
+// Ancestry returns the class and its ancestors, starting from the root of the hierarchy.
+func (c *Class) Ancestry() []*Class {
+	ancestry := []*Class{}
+	for class := c; class != nil; class = class.super {
+		ancestry = append(ancestry, class)
+	}
+	slices.Reverse(ancestry)
+
+	return ancestry
+}
+
+// Depth is the number of ancestors of the class.
+func (c *Class) Depth() int {
+	if c.super == nil {
+		return 0
+	}
+
+	return c.super.Depth() + 1
+}
+
+// IsAbstract reports whether the class declared abstract methods using a goop:"abstract" field,
+// abstract classes aren't required to implement the abstract methods they inherit.
+func (c *Class) IsAbstract() bool {
+	return len(c.abstractInterfaces) > 0
+}
+
+// FindVirtual looks for the virtual method named methodName in the vtables of the class and its ancestors.
+func (c *Class) FindVirtual(methodName string) (*Class, *VTable, *VFunc) {
+	for _, vtable := range c.vtables {
+		if function := vtable.GetMethod(methodName); function != nil {
+			return c, vtable, function
+		}
+	}
+
+	if c.super != nil {
+		return c.super.FindVirtual(methodName)
+	}
+
+	return nil, nil, nil
+}
+
+// Overrides reports whether the class itself (not its ancestors) overrides methodName of vtable.
+func (c *Class) Overrides(vtable *VTable, methodName string) bool {
+	for _, override := range c.overrides {
+		if override.overriddenVtable == vtable && override.HasMethod(methodName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RegisterAbstract adds a virtual method without implementation to the first vtable of the class, or to the one named
+// by the method's //goop:vtable directive.
+func (c *Class) RegisterAbstract(method *package_parser.Function) error {
+	owner, _, function := c.FindVirtual(method.Name)
+	if function != nil {
+		if function.abstract {
+			// Redeclaring an inherited abstract method changes nothing
+			return nil
+		}
+
+		return package_parser.Errorf(method.Position, "abstract method %s of %s is already implemented by %s", method.Name, c.name, owner.name)
+	}
+
+	if !c.HasVTable() {
+		return package_parser.Errorf(c.Position(), "abstract class %s must have a goop:\"vtable\" field to hold the abstract method %s", c.name, method.Name)
+	}
+
+	vtable := c.vtables[0]
+	if requested := VTableDirective(method); requested != "" {
+		if vtable = c.GetVTable(requested); vtable == nil {
+			return package_parser.Errorf(method.Position, "abstract method %s of %s is bound to %s, which isn't a vtable of %s", method.Name, c.name, requested, c.name)
+		}
+	}
+
+	vtable.AddAbstract(method)
+	return nil
+}
+
+// MissingAbstracts returns the abstract methods the class inherits without any implementation,
+// formatted as "<declaring class>.<method>".
+func (c *Class) MissingAbstracts() []string {
+	missing := []string{}
+	for owner := c; owner != nil; owner = owner.super {
+		for _, vtable := range owner.vtables {
+			for _, function := range vtable.functions {
+				if function.abstract && !c.HasImplementation(vtable, function.name) {
+					missing = append(missing, fmt.Sprintf("%s.%s", owner.name, function.name))
+				}
+			}
+		}
+	}
+
+	return missing
+}
+
+// Constructor returns the construct method of the class, or of its nearest ancestor defining one.
+func (c *Class) Constructor() *package_parser.Function {
+	if class := c.ConstructorClass(); class != nil {
+		return class.constructor
+	}
+
+	return nil
+}
+
+// ConstructorClass returns the class defining the construct method returned by Constructor.
+func (c *Class) ConstructorClass() *Class {
+	for class := c; class != nil; class = class.super {
+		if class.constructor != nil {
+			return class
+		}
+	}
+
+	return nil
+}
+
+// CallsSuperConstruct reports whether the construct method of the class calls superConstruct, which it must when the
+// construct method of its super takes arguments. Methods without a parsed body are assumed to call it.
+func (c *Class) CallsSuperConstruct() bool {
+	if c.constructor == nil || c.constructor.BodyNode == nil {
+		return true
+	}
+
+	calls := false
+	ast.Inspect(c.constructor.BodyNode, func(node ast.Node) bool {
+		if selector, isSelector := node.(*ast.SelectorExpr); isSelector && selector.Sel.Name == "superConstruct" {
+			calls = true
+		}
+		return !calls
+	})
+
+	return calls
+}
+
+// VTableOwner returns the class, among the class and its ancestors, introducing vtable. It's never nil for the vtables
+// of the class and for the ones its overrides override.
+func (c *Class) VTableOwner(vtable *VTable) *Class {
+	for class := c; class != nil; class = class.super {
+		if class.OwnsVTable(vtable) {
+			return class
+		}
+	}
+
+	return nil
+}
+
+// NewName is the name of the generated function allocating and constructing instances of the class.
+func (c *Class) NewName() string {
+	if utils.IsExported(c.name) {
+		return "New" + c.name
+	}
+
+	return "new" + utils.Capitalize(c.name)
+}
+
+// HasSuperImplementation reports whether any ancestor of the class implements methodName of vtable.
+func (c *Class) HasSuperImplementation(vtable *VTable, methodName string) bool {
+	return c.SuperImplementation(vtable, methodName) != nil
+}
+
+// SuperImplementation returns the nearest ancestor of the class implementing methodName of vtable, or nil.
+func (c *Class) SuperImplementation(vtable *VTable, methodName string) *Class {
+	for ancestor := c.super; ancestor != nil; ancestor = ancestor.super {
+		if ancestor.Overrides(vtable, methodName) {
+			return ancestor
+		}
+
+		if ancestor.OwnsVTable(vtable) {
+			if function := vtable.GetMethod(methodName); function != nil && !function.abstract {
+				return ancestor
+			}
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// HasImplementation reports whether the class or any of its ancestors implements methodName of vtable.
+func (c *Class) HasImplementation(vtable *VTable, methodName string) bool {
+	if c.Overrides(vtable, methodName) {
+		return true
+	}
+
+	if c.OwnsVTable(vtable) {
+		function := vtable.GetMethod(methodName)
+		return function != nil && !function.abstract
+	}
+
+	return c.HasSuperImplementation(vtable, methodName)
 }
 
-func (c *Class) RegisterVirtual(method *package_parser.Function, vtable *VTable) {
-	if c.HasVTable() && c.vtable == vtable {
-		c.vtable.AddVirtual(method)
-		return
+func (c *Class) RegisterVirtual(method *package_parser.Function, vtable *VTable) error {
+	if c.OwnsVTable(vtable) {
+		if function := vtable.GetMethod(MethodVirtualName(method)); function != nil && function.abstract {
+			return package_parser.Errorf(method.Position, "%s implements %s which it declared abstract", c.name, method.Name)
+		}
+
+		vtable.AddVirtual(method)
+		return nil
 	}
 
 	for _, override := range c.overrides {
 		if override.overriddenVtable == vtable {
 			override.AddOverride(method)
-			return
+			return nil
 		}
 	}
 
 	override := &Override{overriddenVtable: vtable}
 	c.overrides = append(c.overrides, override)
 	override.AddOverride(method)
+	return nil
 }
 
 type VTable struct {
-	name      string
-	functions []VFunc
+	name string
+	// typeParams are the type parameters of a vtable of a generic class, which are the ones of the class
+	typeParams []*package_parser.TypeParam
+	functions  []VFunc
+	// static vtables are shared by all the instances of a class, which only hold a pointer to them
+	static bool
 }
 
 func (v *VTable) HasMethod(methodName string) bool {
-	for _, function := range v.functions {
-		if function.name == methodName {
-			return true
+	return v.GetMethod(methodName) != nil
+}
+
+func (v *VTable) GetMethod(methodName string) *VFunc {
+	for i := range v.functions {
+		if v.functions[i].name == methodName {
+			return &v.functions[i]
 		}
 	}
-	return false
+	return nil
 }
 
 func (v *VTable) AddVirtual(method *package_parser.Function) {
 	v.functions = append(v.functions, functionToVFunc(method))
 }
 
-func (v *VTable) IsInitName() string {
-	return fmt.Sprintf("is%vInit", utils.Capitalize(v.name))
+func (v *VTable) AddAbstract(method *package_parser.Function) {
+	function := functionToVFunc(method)
+	function.abstract = true
+	v.functions = append(v.functions, function)
+}
+
+func (v *VTable) InitOnceName() string {
+	return fmt.Sprintf("%vInitOnce", v.name)
+}
+
+// SlotsName is the name of the struct holding the function pointers of a static vtable.
+func (v *VTable) SlotsName() string {
+	return fmt.Sprintf("%vSlots", v.name)
+}
+
+// SlotsValueName is the name of the package level static vtable of class.
+func (v *VTable) SlotsValueName(class *Class) string {
+	return fmt.Sprintf("%vSlotsOf%v", v.name, utils.Capitalize(class.name))
 }
 
 type VFunc struct {
 	name      string
 	signature string
+	abstract  bool
+	method    *package_parser.Function
 }
 
 type Override struct {
@@ -163,6 +544,15 @@ type Override struct {
 	functions        []VFunc
 }
 
+func (o *Override) HasMethod(methodName string) bool {
+	for _, function := range o.functions {
+		if function.name == methodName {
+			return true
+		}
+	}
+	return false
+}
+
 func (o *Override) AddOverride(method *package_parser.Function) {
 	o.functions = append(o.functions, functionToVFunc(method))
 }
@@ -171,9 +561,14 @@ func functionToVFunc(method *package_parser.Function) VFunc {
 	return VFunc{
 		name:      MethodVirtualName(method),
 		signature: method.Signature(),
+		method:    method,
 	}
 }
 
+func IsConstructor(method *package_parser.Function) bool {
+	return method.Name == "construct"
+}
+
 func IsVirtualMethod(method *package_parser.Function) bool {
 	return strings.HasSuffix(method.Name, "Impl")
 }
@@ -181,3 +576,82 @@ func IsVirtualMethod(method *package_parser.Function) bool {
 func MethodVirtualName(method *package_parser.Function) string {
 	return strings.TrimSuffix(method.Name, "Impl")
 }
+
+// VTableDirective returns the name of the vtable the method is bound to by a "//goop:vtable <name>" directive,
+// or an empty string if it has none.
+func VTableDirective(method *package_parser.Function) string {
+	for _, directive := range method.Directives {
+		if name, found := strings.CutPrefix(directive, "goop:vtable "); found {
+			return strings.TrimSpace(name)
+		}
+	}
+
+	return ""
+}
+
+// MatchesSignature reports whether override has the exact signature of the overridden method, which is only known when
+// both were type checked, otherwise they're assumed to match.
+func MatchesSignature(overridden *package_parser.Function, override *package_parser.Function) bool {
+	if overridden == nil {
+		return true
+	}
+
+	expected, actual := overridden.ResolvedSignature(), override.ResolvedSignature()
+	return expected == nil || actual == nil || types.Identical(expected, actual)
+}
+
+// SplitTypeArgs splits an instantiated type expression to the type and its type arguments, e.g. Cache[K, []V] to Cache
+// and [K, []V].
+func SplitTypeArgs(typeExpr string) (string, []string) {
+	start := strings.Index(typeExpr, "[")
+	if start <= 0 || !strings.HasSuffix(typeExpr, "]") {
+		return typeExpr, nil
+	}
+
+	args := []string{}
+	depth, argStart := 0, start+1
+	for i := argStart; i < len(typeExpr)-1; i++ {
+		switch typeExpr[i] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(typeExpr[argStart:i]))
+				argStart = i + 1
+			}
+		}
+	}
+
+	return typeExpr[:start], append(args, strings.TrimSpace(typeExpr[argStart:len(typeExpr)-1]))
+}
+
+// SubstituteTypeParams replaces the type parameters in a type expression with the matching type arguments.
+func SubstituteTypeParams(typeExpr string, params []*package_parser.TypeParam, args []string) string {
+	replacements := map[string]string{}
+	for i, param := range params {
+		if i < len(args) {
+			replacements[param.Name] = args[i]
+		}
+	}
+
+	return utils.ReplaceIdentifiers(typeExpr, replacements)
+}
+
+// The hooks are the exported methods generated for extensible classes, letting classes of other packages inherit them.
+
+// SetHookName is the name of the hook assigning the slot of a virtual method in the vtable.
+func SetHookName(virtualName string) string {
+	return "GoopSet" + utils.Capitalize(virtualName)
+}
+
+// ImplHookName is the name of the hook calling the implementation of an unexported virtual method.
+func ImplHookName(virtualName string) string {
+	return "Goop" + utils.Capitalize(virtualName) + "Impl"
+}
+
+// SuperCallName is the name of the method calling the nearest ancestor implementation of a virtual method.
+func SuperCallName(virtualName string) string {
+	return "super" + utils.Capitalize(virtualName)
+}
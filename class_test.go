@@ -0,0 +1,277 @@
+package main
+
+import (
+	"github.com/tadnir/goop/package_parser"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const widgetSource = `package widgets
+
+type Widget struct {
+	lifecycleVtable ` + "`goop:\"vtable\"`" + `
+	renderVtable    ` + "`goop:\"vtable,static\"`" + `
+}
+
+func (w *Widget) startImpl() {}
+
+//goop:vtable renderVtable
+func (w *Widget) drawImpl() string { return "widget" }
+`
+
+// vtableOf returns the name of the vtable of class holding the virtual method, which it introduces or overrides.
+func vtableOf(class *Class, method string) string {
+	for _, vtable := range class.vtables {
+		if vtable.GetMethod(method) != nil {
+			return vtable.name
+		}
+	}
+	for _, override := range class.overrides {
+		if override.HasMethod(method) {
+			return override.overriddenVtable.name
+		}
+	}
+
+	return ""
+}
+
+func TestChooseVTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		class  string
+		method string
+		vtable string
+	}{
+		{name: "first vtable without a directive", class: "Widget", method: "start", vtable: "lifecycleVtable"},
+		{name: "second vtable by directive", class: "Widget", method: "draw", vtable: "renderVtable"},
+		{
+			name: "override without a directive",
+			source: `
+type Button struct {
+	Widget ` + "`goop:\"super\"`" + `
+}
+
+func (b *Button) drawImpl() string { return "button" }
+`,
+			class:  "Button",
+			method: "draw",
+			vtable: "renderVtable",
+		},
+		{
+			name: "override with the declaring vtable's directive",
+			source: `
+type Button struct {
+	Widget ` + "`goop:\"super\"`" + `
+}
+
+//goop:vtable renderVtable
+func (b *Button) drawImpl() string { return "button" }
+`,
+			class:  "Button",
+			method: "draw",
+			vtable: "renderVtable",
+		},
+		{
+			// A new method of a class with several vtables, which could go to any of them
+			name: "ambiguous method without a directive",
+			source: `
+type Button struct {
+	Widget       ` + "`goop:\"super\"`" + `
+	buttonVtable ` + "`goop:\"vtable\"`" + `
+	clickVtable  ` + "`goop:\"vtable\"`" + `
+}
+
+func (b *Button) clickImpl() {}
+`,
+			class:  "Button",
+			method: "click",
+			vtable: "buttonVtable",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := goopModule(t, map[string]string{"widget.go": widgetSource + test.source})
+			loaded, err := LoadPackages(dir, []string{"./..."}, package_parser.ParseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if vtable := vtableOf(loaded[0].Classes.GetClass(test.class), test.method); vtable != test.vtable {
+				t.Errorf("expected %s of %s in %s, got %q", test.method, test.class, test.vtable, vtable)
+			}
+
+			generateModule(t, dir, false)
+			goCommand(t, dir, "vet", "./...")
+		})
+	}
+}
+
+func TestChooseVTableErrors(t *testing.T) {
+	runClassTests(t, []classTest{
+		{
+			name: "override with a conflicting directive",
+			files: map[string]string{
+				"widget.go": widgetSource,
+				"button.go": `package widgets
+
+type Button struct {
+	Widget ` + "`goop:\"super\"`" + `
+}
+
+//goop:vtable lifecycleVtable
+func (b *Button) drawImpl() string { return "button" }
+`,
+			},
+			err: "button.go:8:1: drawImpl of Button is bound to lifecycleVtable, but Widget declares it in renderVtable",
+		},
+		{
+			name: "directive naming another class's vtable",
+			files: map[string]string{
+				"widget.go": widgetSource,
+				"button.go": `package widgets
+
+type Button struct {
+	Widget       ` + "`goop:\"super\"`" + `
+	buttonVtable ` + "`goop:\"vtable\"`" + `
+}
+
+//goop:vtable renderVtable
+func (b *Button) pressImpl() {}
+`,
+			},
+			err: "button.go:9:1: pressImpl of Button is bound to renderVtable, which isn't a vtable of Button",
+		},
+		{
+			name: "directive naming an unknown vtable",
+			files: map[string]string{
+				"widget.go": widgetSource + `
+//goop:vtable styleVtable
+func (w *Widget) styleImpl() {}
+`,
+			},
+			err: "widget.go:14:1: styleImpl of Widget is bound to styleVtable, which isn't a vtable of Widget",
+		},
+	})
+}
+
+func TestSplitTypeArgs(t *testing.T) {
+	tests := []struct {
+		typeExpr string
+		name     string
+		args     []string
+	}{
+		{typeExpr: "Repo", name: "Repo"},
+		{typeExpr: "[]int", name: "[]int"},
+		{typeExpr: "Repo[T]", name: "Repo", args: []string{"T"}},
+		{typeExpr: "Cache[K, []V]", name: "Cache", args: []string{"K", "[]V"}},
+		{typeExpr: "Repo[map[K]V]", name: "Repo", args: []string{"map[K]V"}},
+		{typeExpr: "Pair[func(a, b int) (int, error), T]", name: "Pair", args: []string{"func(a, b int) (int, error)", "T"}},
+	}
+
+	for _, test := range tests {
+		name, args := SplitTypeArgs(test.typeExpr)
+		if name != test.name || !slices.Equal(args, test.args) {
+			t.Errorf("SplitTypeArgs(%q): expected %q %q, got %q %q", test.typeExpr, test.name, test.args, name, args)
+		}
+	}
+}
+
+func TestSubstituteTypeParams(t *testing.T) {
+	params := []*package_parser.TypeParam{{Name: "K", Constraint: "comparable"}, {Name: "T", Constraint: "any"}}
+	tests := []struct {
+		typeExpr string
+		args     []string
+		expected string
+	}{
+		{typeExpr: "func(item T) bool", args: []string{"string", "V"}, expected: "func(item V) bool"},
+		{typeExpr: "map[K][]T", args: []string{"string", "int"}, expected: "map[string][]int"},
+		{typeExpr: "func(t T) Tree[T]", args: []string{"K", "V"}, expected: "func(t V) Tree[V]"},
+		// Selected names of other packages aren't type parameters
+		{typeExpr: "func(k pkg.K) T", args: []string{"string", "int"}, expected: "func(k pkg.K) int"},
+		// Missing arguments leave the parameters in place
+		{typeExpr: "map[K]T", args: []string{"string"}, expected: "map[string]T"},
+	}
+
+	for _, test := range tests {
+		if substituted := SubstituteTypeParams(test.typeExpr, params, test.args); substituted != test.expected {
+			t.Errorf("SubstituteTypeParams(%q, %q): expected %q, got %q", test.typeExpr, test.args, test.expected, substituted)
+		}
+	}
+}
+
+func TestGenericHierarchy(t *testing.T) {
+	dir := goopModule(t, map[string]string{
+		"repo.go": `package main
+
+import "fmt"
+
+type Repo[T any] struct {
+	repoVtable[T] ` + "`goop:\"vtable\"`" + `
+	items         []T
+}
+
+func (r *Repo[T]) SaveImpl(item T) bool {
+	r.items = append(r.items, item)
+	return true
+}
+
+func (r *Repo[T]) allImpl() map[int]T {
+	all := map[int]T{}
+	for i, item := range r.items {
+		all[i] = item
+	}
+	return all
+}
+
+// Cache renames the type parameter of Repo
+type Cache[K comparable, V any] struct {
+	Repo[V] ` + "`goop:\"super\"`" + `
+	keys    []K
+}
+
+func (c *Cache[K, V]) SaveImpl(item V) bool {
+	return c.superSave(item)
+}
+
+type IntRepo struct {
+	Repo[int] ` + "`goop:\"super\"`" + `
+}
+
+func (r *IntRepo) SaveImpl(item int) bool {
+	return item > 0 && r.superSave(item)
+}
+
+func (r *IntRepo) allImpl() map[int]int {
+	return r.superAll()
+}
+
+func main() {
+	cache := NewCache[string, float64]()
+	cache.Save(1.5)
+	ints := NewIntRepo()
+	fmt.Println(ints.Save(-1), ints.Save(2), ints.all(), cache.all())
+}
+`,
+	})
+
+	generated := generateModule(t, dir, false)
+	source := generated[filepath.Join(dir, "repo_goop.go")]
+	for _, expected := range []string{
+		"func (this *Cache[K, V]) superSave(item V) bool",
+		"func NewCache[K comparable, V any]() *Cache[K, V]",
+		"func (this *IntRepo) superSave(item int) bool",
+		"func (this *IntRepo) superAll() map[int]int",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("generated file doesn't contain %q:\n%s", expected, source)
+		}
+	}
+
+	if out := goCommand(t, dir, "run", "."); out != "false true map[0:2] map[0:1.5]\n" {
+		t.Errorf("unexpected output of the generic hierarchy %q", out)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"github.com/tadnir/goop/utils"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// The diagrams render the exported model of the classes, see exportPackage.
+
+// diagramClass is a class of a diagram, or a class of another package only appearing as a super.
+type diagramClass struct {
+	id    string
+	label string
+	// class is nil for the supers of other packages
+	class *exportedClass
+}
+
+// diagramMember is a line of a class of a diagram, a virtual method it introduces or overrides.
+type diagramMember struct {
+	name     string
+	params   string
+	results  string
+	abstract bool
+	// overrides is the class introducing the overridden virtual method, empty for the ones the class introduces
+	overrides string
+}
+
+// diagramEdge is an inheritance edge from a class to its super.
+type diagramEdge struct {
+	from, to string
+}
+
+var diagramIdChars = regexp.MustCompile(`[^\w]`)
+
+// diagramId is the identifier of a class in diagrams, unique across packages.
+func diagramId(ref *exportedClassRef) string {
+	return diagramIdChars.ReplaceAllString(ref.ImportPath+"."+ref.Name, "_")
+}
+
+// ExportDiagram writes the class diagram of the classes of the packages, in the format "dot", "mermaid" or "plantuml".
+// The diagram shows the inheritance of the classes, the virtual methods each class introduces in its vtables, and the
+// ones it overrides.
+func ExportDiagram(w io.Writer, packages []*LoadedPackage, format string) error {
+	classes, edges := diagramGraph(packages)
+	switch format {
+	case "dot":
+		writeDot(w, classes, edges)
+	case "mermaid":
+		writeMermaid(w, classes, edges)
+	case "plantuml":
+		writePlantUML(w, classes, edges)
+	default:
+		return fmt.Errorf("unknown diagram format %q", format)
+	}
+
+	return nil
+}
+
+func diagramGraph(packages []*LoadedPackage) ([]*diagramClass, []diagramEdge) {
+	classes := []*diagramClass{}
+	edges := []diagramEdge{}
+	known := map[string]bool{}
+	externalSupers := []*exportedClassRef{}
+	for _, pack := range packages {
+		exported := exportPackage(pack)
+		for _, class := range exported.Classes {
+			ref := &exportedClassRef{Name: class.Name, ImportPath: exported.ImportPath}
+			label := class.Name
+			if len(class.TypeParams) > 0 {
+				params := []string{}
+				for _, param := range class.TypeParams {
+					params = append(params, param.Name+" "+param.Constraint)
+				}
+				label += "[" + strings.Join(params, ", ") + "]"
+			}
+
+			classes = append(classes, &diagramClass{id: diagramId(ref), label: label, class: class})
+			known[diagramId(ref)] = true
+			if class.Super != nil {
+				edges = append(edges, diagramEdge{from: diagramId(ref), to: diagramId(class.Super)})
+				externalSupers = append(externalSupers, class.Super)
+			}
+		}
+	}
+
+	// Supers declared by packages which aren't exported appear without their members
+	for _, super := range externalSupers {
+		if !known[diagramId(super)] {
+			known[diagramId(super)] = true
+			classes = append(classes, &diagramClass{id: diagramId(super), label: path.Base(super.ImportPath) + "." + super.Name})
+		}
+	}
+
+	return classes, edges
+}
+
+// vtableMembers returns the virtual methods of vtable, which the class introduces.
+func vtableMembers(vtable *exportedVTable) []diagramMember {
+	members := []diagramMember{}
+	for _, slot := range vtable.Slots {
+		params, results := splitSignature(slot.Signature)
+		members = append(members, diagramMember{name: slot.Name, params: params, results: results, abstract: slot.Abstract})
+	}
+
+	return members
+}
+
+// overrideMembers returns the virtual methods the class overrides.
+func overrideMembers(class *exportedClass) []diagramMember {
+	members := []diagramMember{}
+	for _, override := range class.Overrides {
+		for _, method := range override.Methods {
+			params, results := splitSignature(method.Signature)
+			members = append(members, diagramMember{name: method.Name, params: params, results: results, overrides: override.Owner.Name})
+		}
+	}
+
+	return members
+}
+
+// splitSignature splits a function type to its parameters and results, e.g. "func(a int) (b, error)" to "(a int)"
+// and "(b, error)".
+func splitSignature(signature string) (string, string) {
+	signature = strings.TrimPrefix(signature, "func")
+	depth := 0
+	for i, char := range signature {
+		switch char {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return signature[:i+1], strings.TrimSpace(signature[i+1:])
+			}
+		}
+	}
+
+	return signature, ""
+}
+
+// visibility is the UML visibility of a method, public when it's exported.
+func (m diagramMember) visibility() string {
+	if utils.IsExported(m.name) {
+		return "+"
+	}
+
+	return "-"
+}
+
+func writeDot(w io.Writer, classes []*diagramClass, edges []diagramEdge) {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`).Replace
+	fmt.Fprintf(w, "digraph goop {\n")
+	fmt.Fprintf(w, "\trankdir=BT;\n")
+	fmt.Fprintf(w, "\tnode [shape=record, fontname=\"Helvetica\"];\n")
+	fmt.Fprintf(w, "\tedge [arrowhead=empty];\n")
+	for _, class := range classes {
+		sections := []string{escape(class.label) + `\l`}
+		if class.class == nil {
+			fmt.Fprintf(w, "\t\"%s\" [label=\"{%s}\", style=dashed];\n", class.id, sections[0])
+			continue
+		}
+
+		if class.class.Abstract {
+			sections[0] += `«abstract»\l`
+		}
+
+		for _, vtable := range class.class.VTables {
+			var sb strings.Builder
+			sb.WriteString(escape(vtable.Name))
+			if vtable.Static {
+				sb.WriteString(" (static)")
+			}
+			sb.WriteString(`\l`)
+			for _, member := range vtableMembers(vtable) {
+				if member.abstract {
+					sb.WriteString("abstract ")
+				}
+				sb.WriteString(escape(member.visibility() + member.name + member.params + " " + member.results))
+				sb.WriteString(`\l`)
+			}
+			sections = append(sections, sb.String())
+		}
+
+		if overrides := overrideMembers(class.class); len(overrides) > 0 {
+			var sb strings.Builder
+			sb.WriteString(`overrides\l`)
+			for _, member := range overrides {
+				sb.WriteString(escape(fmt.Sprintf("%s%s%s %s (from %s)", member.visibility(), member.name, member.params, member.results, member.overrides)))
+				sb.WriteString(`\l`)
+			}
+			sections = append(sections, sb.String())
+		}
+
+		fmt.Fprintf(w, "\t\"%s\" [label=\"{%s}\"];\n", class.id, strings.Join(sections, "|"))
+	}
+
+	for _, edge := range edges {
+		fmt.Fprintf(w, "\t\"%s\" -> \"%s\";\n", edge.from, edge.to)
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+func writeMermaid(w io.Writer, classes []*diagramClass, edges []diagramEdge) {
+	// Braces end the body of a class, and are written as entity codes instead
+	escape := strings.NewReplacer("{", "#123;", "}", "#125;", `"`, "#quot;").Replace
+	fmt.Fprintf(w, "classDiagram\n")
+	for _, class := range classes {
+		if class.class == nil {
+			fmt.Fprintf(w, "\tclass %s[\"%s\"]\n", class.id, escape(class.label))
+			continue
+		}
+
+		fmt.Fprintf(w, "\tclass %s[\"%s\"] {\n", class.id, escape(class.label))
+		if class.class.Abstract {
+			fmt.Fprintf(w, "\t\t<<abstract>>\n")
+		}
+		for _, vtable := range class.class.VTables {
+			fmt.Fprintf(w, "\t\t-%s vtable\n", vtable.Name)
+			for _, member := range vtableMembers(vtable) {
+				classifier := ""
+				if member.abstract {
+					classifier = "*"
+				}
+				fmt.Fprintf(w, "\t\t%s\n", escape(strings.TrimSpace(member.visibility()+member.name+member.params+classifier+" "+member.results)))
+			}
+		}
+		for _, member := range overrideMembers(class.class) {
+			fmt.Fprintf(w, "\t\t%s\n", escape(strings.TrimSpace(fmt.Sprintf("%s%s%s %s overrides %s", member.visibility(), member.name, member.params, member.results, member.overrides))))
+		}
+		fmt.Fprintf(w, "\t}\n")
+	}
+
+	for _, edge := range edges {
+		fmt.Fprintf(w, "\t%s <|-- %s\n", edge.to, edge.from)
+	}
+}
+
+func writePlantUML(w io.Writer, classes []*diagramClass, edges []diagramEdge) {
+	// Quotes end the names of the classes, and are written as unicode escapes instead
+	escape := strings.NewReplacer(`"`, "<U+0022>").Replace
+	fmt.Fprintf(w, "@startuml\n")
+	for _, class := range classes {
+		if class.class == nil {
+			fmt.Fprintf(w, "class \"%s\" as %s\n", escape(class.label), class.id)
+			continue
+		}
+
+		kind := "class"
+		if class.class.Abstract {
+			kind = "abstract class"
+		}
+		fmt.Fprintf(w, "%s \"%s\" as %s {\n", kind, escape(class.label), class.id)
+		for _, vtable := range class.class.VTables {
+			static := ""
+			if vtable.Static {
+				static = " (static)"
+			}
+			fmt.Fprintf(w, "\t.. %s%s ..\n", vtable.Name, static)
+			for _, member := range vtableMembers(vtable) {
+				abstract := ""
+				if member.abstract {
+					abstract = "{abstract} "
+				}
+				fmt.Fprintf(w, "\t%s%s\n", abstract, escape(strings.TrimSpace(member.visibility()+member.name+member.params+" "+member.results)))
+			}
+		}
+		if overrides := overrideMembers(class.class); len(overrides) > 0 {
+			fmt.Fprintf(w, "\t.. overrides ..\n")
+			for _, member := range overrides {
+				fmt.Fprintf(w, "\t%s\n", escape(fmt.Sprintf("%s%s%s %s (from %s)", member.visibility(), member.name, member.params, member.results, member.overrides)))
+			}
+		}
+		fmt.Fprintf(w, "}\n")
+	}
+
+	for _, edge := range edges {
+		fmt.Fprintf(w, "%s <|-- %s\n", edge.to, edge.from)
+	}
+	fmt.Fprintf(w, "@enduml\n")
+}
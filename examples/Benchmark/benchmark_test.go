@@ -0,0 +1,38 @@
+// Package benchmark compares the per-instance vtables with the static vtables (goop:"vtable,static") on a hierarchy of
+// 8 classes. Run `go generate ./...` before running the benchmarks with `go test -bench . -benchmem`.
+package benchmark
+
+import (
+	"goopbenchmark/dynamic"
+	"goopbenchmark/static"
+	"testing"
+	"unsafe"
+)
+
+func BenchmarkConstructionDynamic(b *testing.B) {
+	b.ReportMetric(float64(unsafe.Sizeof(dynamic.L7{})), "B/object")
+	for i := 0; i < b.N; i++ {
+		dynamic.NewL7(i)
+	}
+}
+
+func BenchmarkConstructionStatic(b *testing.B) {
+	b.ReportMetric(float64(unsafe.Sizeof(static.L7{})), "B/object")
+	for i := 0; i < b.N; i++ {
+		static.NewL7(i)
+	}
+}
+
+func BenchmarkVirtualCallsDynamic(b *testing.B) {
+	object := dynamic.NewL7(1)
+	for i := 0; i < b.N; i++ {
+		dynamic.Call(&object.L0)
+	}
+}
+
+func BenchmarkVirtualCallsStatic(b *testing.B) {
+	object := static.NewL7(1)
+	for i := 0; i < b.N; i++ {
+		static.Call(&object.L0)
+	}
+}
@@ -0,0 +1,174 @@
+package dynamic
+
+//go:generate go run github.com/tadnir/goop
+
+// L0 is the root of a hierarchy of 8 classes, each overriding all of the 4 virtual methods.
+type L0 struct {
+	l0Vtable `goop:"vtable"`
+	value    int
+}
+
+func (l *L0) construct(value int) {
+	l.value = value
+}
+
+func (l *L0) firstImpl() int {
+	return l.value + 0
+}
+
+func (l *L0) secondImpl() int {
+	return l.value + 1
+}
+
+func (l *L0) thirdImpl() int {
+	return l.value + 2
+}
+
+func (l *L0) fourthImpl() int {
+	return l.value + 3
+}
+
+type L1 struct {
+	L0 `goop:"super"`
+}
+
+func (l *L1) firstImpl() int {
+	return l.value + 4
+}
+
+func (l *L1) secondImpl() int {
+	return l.value + 5
+}
+
+func (l *L1) thirdImpl() int {
+	return l.value + 6
+}
+
+func (l *L1) fourthImpl() int {
+	return l.value + 7
+}
+
+type L2 struct {
+	L1 `goop:"super"`
+}
+
+func (l *L2) firstImpl() int {
+	return l.value + 8
+}
+
+func (l *L2) secondImpl() int {
+	return l.value + 9
+}
+
+func (l *L2) thirdImpl() int {
+	return l.value + 10
+}
+
+func (l *L2) fourthImpl() int {
+	return l.value + 11
+}
+
+type L3 struct {
+	L2 `goop:"super"`
+}
+
+func (l *L3) firstImpl() int {
+	return l.value + 12
+}
+
+func (l *L3) secondImpl() int {
+	return l.value + 13
+}
+
+func (l *L3) thirdImpl() int {
+	return l.value + 14
+}
+
+func (l *L3) fourthImpl() int {
+	return l.value + 15
+}
+
+type L4 struct {
+	L3 `goop:"super"`
+}
+
+func (l *L4) firstImpl() int {
+	return l.value + 16
+}
+
+func (l *L4) secondImpl() int {
+	return l.value + 17
+}
+
+func (l *L4) thirdImpl() int {
+	return l.value + 18
+}
+
+func (l *L4) fourthImpl() int {
+	return l.value + 19
+}
+
+type L5 struct {
+	L4 `goop:"super"`
+}
+
+func (l *L5) firstImpl() int {
+	return l.value + 20
+}
+
+func (l *L5) secondImpl() int {
+	return l.value + 21
+}
+
+func (l *L5) thirdImpl() int {
+	return l.value + 22
+}
+
+func (l *L5) fourthImpl() int {
+	return l.value + 23
+}
+
+type L6 struct {
+	L5 `goop:"super"`
+}
+
+func (l *L6) firstImpl() int {
+	return l.value + 24
+}
+
+func (l *L6) secondImpl() int {
+	return l.value + 25
+}
+
+func (l *L6) thirdImpl() int {
+	return l.value + 26
+}
+
+func (l *L6) fourthImpl() int {
+	return l.value + 27
+}
+
+type L7 struct {
+	L6 `goop:"super"`
+}
+
+func (l *L7) firstImpl() int {
+	return l.value + 28
+}
+
+func (l *L7) secondImpl() int {
+	return l.value + 29
+}
+
+func (l *L7) thirdImpl() int {
+	return l.value + 30
+}
+
+func (l *L7) fourthImpl() int {
+	return l.value + 31
+}
+
+// Call calls all the virtual methods of l.
+func Call(l *L0) int {
+	return l.first() + l.second() + l.third() + l.fourth()
+}
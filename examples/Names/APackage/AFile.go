@@ -6,10 +6,8 @@ type A struct {
 	firstName string
 }
 
-func (a *A) New(firstName string) *A {
-	a.initClass()
+func (a *A) construct(firstName string) {
 	a.firstName = firstName
-	return a
 }
 
 func (a *A) getNameImpl() string {
@@ -6,12 +6,12 @@ type B struct {
 	lastName string
 }
 
-func (b *B) New(firstName string, lastName string) *B {
-	b.super().New(firstName)
+func (b *B) construct(firstName string, lastName string) {
+	b.superConstruct(firstName)
 	b.lastName = lastName
-	return b
 }
 
 func (b *B) getNameImpl() string {
-	return b.firstName + " " + b.lastName
+	// calls A's getNameImpl
+	return b.superGetName() + " " + b.lastName
 }
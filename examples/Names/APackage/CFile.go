@@ -6,10 +6,9 @@ type C struct {
 	middleName string
 }
 
-func (c *C) New(firstName string, middleName string, lastName string) *C {
-	c.super().New(firstName, lastName)
+func (c *C) construct(firstName string, middleName string, lastName string) {
+	c.superConstruct(firstName, lastName)
 	c.middleName = middleName
-	return c
 }
 
 func (c *C) getNameImpl() string {
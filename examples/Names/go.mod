@@ -2,4 +2,6 @@ module goopexample
 
 go 1.23
 
-require github.com/tadnir/goop v1.0.0
\ No newline at end of file
+require github.com/tadnir/goop v1.0.0
+
+replace github.com/tadnir/goop => ../..
@@ -3,12 +3,12 @@ package main
 import "goopexample/APackage"
 
 func main() {
-	a := new(APackage.A).New("John")
+	a := APackage.NewA("John")
 	a.Foo() // prints "John"
 
-	b := new(APackage.B).New("John", "Doe")
+	b := APackage.NewB("John", "Doe")
 	b.Foo() // prints "John Doe"
 
-	c := new(APackage.C).New("John", "Jimmy", "Doe")
+	c := APackage.NewC("John", "Jimmy", "Doe")
 	c.Foo() // prints "John Doe"
 }
@@ -0,0 +1,7 @@
+module goopshapes
+
+go 1.23
+
+require github.com/tadnir/goop v1.0.0
+
+replace github.com/tadnir/goop => ../..
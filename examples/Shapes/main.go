@@ -0,0 +1,10 @@
+package main
+
+import "goopshapes/shapes"
+
+func main() {
+	// Describer is generated with the exported virtual methods of Shape
+	for _, shape := range []shapes.Describer{shapes.NewRect(2, 3), shapes.NewSquare(2)} {
+		println(shape.Describe()) // prints "rect of area 6.00", then "square of area 4.00"
+	}
+}
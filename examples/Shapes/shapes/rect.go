@@ -0,0 +1,16 @@
+package shapes
+
+//go:generate go run github.com/tadnir/goop
+type Rect struct {
+	Shape         `goop:"super"`
+	width, height float64
+}
+
+func (r *Rect) construct(width float64, height float64) {
+	r.superConstruct("rect")
+	r.width, r.height = width, height
+}
+
+func (r *Rect) areaImpl() float64 {
+	return r.width * r.height
+}
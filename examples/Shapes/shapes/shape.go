@@ -0,0 +1,25 @@
+package shapes
+
+import "fmt"
+
+//go:generate go run github.com/tadnir/goop
+type Shape struct {
+	shapeVtable `goop:"vtable"`
+	_           [0]shapeAbstract `goop:"abstract"`
+	_           [0]Describer     `goop:"interface"`
+	name        string
+}
+
+// shapeAbstract lists the methods every concrete shape implements
+type shapeAbstract interface {
+	area() float64
+}
+
+func (s *Shape) construct(name string) {
+	s.name = name
+}
+
+func (s *Shape) DescribeImpl() string {
+	// area is abstract, the call reaches the implementation of the concrete shape
+	return fmt.Sprintf("%s of area %.2f", s.name, s.area())
+}
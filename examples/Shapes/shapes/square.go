@@ -0,0 +1,11 @@
+package shapes
+
+//go:generate go run github.com/tadnir/goop
+type Square struct {
+	Rect `goop:"super"`
+}
+
+func (s *Square) construct(side float64) {
+	s.superConstruct(side, side)
+	s.name = "square"
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/tadnir/goop/package_parser"
+	"go/token"
+	"io"
+	"path/filepath"
+)
+
+// ExportSchemaVersion is the version of the exported class model, changed whenever a change of the schema would
+// break its readers. Adding fields doesn't change it.
+const ExportSchemaVersion = 1
+
+// The exported model of the classes, see ExportJSON.
+
+type exportedModel struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	Packages      []*exportedPackage `json:"packages"`
+}
+
+type exportedPackage struct {
+	ImportPath string           `json:"importPath"`
+	Name       string           `json:"name"`
+	Dir        string           `json:"dir"`
+	Classes    []*exportedClass `json:"classes"`
+}
+
+type exportedClass struct {
+	Name       string               `json:"name"`
+	TypeParams []*exportedTypeParam `json:"typeParams,omitempty"`
+	Position   *exportedPosition    `json:"position,omitempty"`
+	Super      *exportedClassRef    `json:"super,omitempty"`
+	Abstract   bool                 `json:"abstract"`
+	// AbstractInterfaces are the interfaces listing the abstract methods of the class
+	AbstractInterfaces []string            `json:"abstractInterfaces,omitempty"`
+	VTables            []*exportedVTable   `json:"vtables,omitempty"`
+	Overrides          []*exportedOverride `json:"overrides,omitempty"`
+	Constructor        *exportedMethod     `json:"constructor,omitempty"`
+	Implements         []string            `json:"implements,omitempty"`
+	Interfaces         []string            `json:"interfaces,omitempty"`
+}
+
+type exportedTypeParam struct {
+	Name       string `json:"name"`
+	Constraint string `json:"constraint"`
+}
+
+// exportedClassRef refers to a class, which may be declared by another package.
+type exportedClassRef struct {
+	Name       string   `json:"name"`
+	ImportPath string   `json:"importPath"`
+	TypeArgs   []string `json:"typeArgs,omitempty"`
+}
+
+type exportedVTable struct {
+	Name   string          `json:"name"`
+	Static bool            `json:"static"`
+	Slots  []*exportedSlot `json:"slots"`
+}
+
+type exportedSlot struct {
+	Name      string            `json:"name"`
+	Signature string            `json:"signature"`
+	Abstract  bool              `json:"abstract"`
+	Position  *exportedPosition `json:"position,omitempty"`
+}
+
+type exportedOverride struct {
+	VTable string `json:"vtable"`
+	// Owner is the class introducing the vtable, always set as the overridden vtables are the ones of the ancestors
+	Owner   *exportedClassRef `json:"owner"`
+	Methods []*exportedMethod `json:"methods"`
+}
+
+type exportedMethod struct {
+	Name      string            `json:"name"`
+	Signature string            `json:"signature"`
+	Position  *exportedPosition `json:"position,omitempty"`
+}
+
+type exportedPosition struct {
+	// File is relative to the directory of the package
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// ExportJSON writes the classes of the packages, their supers, vtables and overrides as JSON.
+func ExportJSON(w io.Writer, packages []*LoadedPackage) error {
+	model := &exportedModel{SchemaVersion: ExportSchemaVersion, Packages: []*exportedPackage{}}
+	for _, pack := range packages {
+		model.Packages = append(model.Packages, exportPackage(pack))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(model)
+}
+
+// ImportPath is the import path of the loaded package, suffixed by _test for external test packages.
+func (p *LoadedPackage) ImportPath() string {
+	if p.Data.GetName() != p.Listed.Name {
+		return p.Listed.ImportPath + "_test"
+	}
+
+	return p.Listed.ImportPath
+}
+
+func exportPackage(pack *LoadedPackage) *exportedPackage {
+	exported := &exportedPackage{
+		ImportPath: pack.ImportPath(),
+		Name:       pack.Data.GetName(),
+		Dir:        pack.Listed.Dir,
+		Classes:    []*exportedClass{},
+	}
+
+	position := func(position token.Position) *exportedPosition {
+		if !position.IsValid() {
+			return nil
+		}
+
+		file, err := filepath.Rel(pack.Listed.Dir, position.Filename)
+		if err != nil {
+			file = position.Filename
+		}
+		return &exportedPosition{File: filepath.ToSlash(file), Line: position.Line, Column: position.Column}
+	}
+
+	classRef := func(class *Class) *exportedClassRef {
+		if class.IsExternal() {
+			return &exportedClassRef{Name: class.name, ImportPath: class.pkg.path}
+		}
+
+		return &exportedClassRef{Name: class.name, ImportPath: pack.ImportPath()}
+	}
+
+	method := func(function *package_parser.Function, name string) *exportedMethod {
+		return &exportedMethod{Name: name, Signature: function.Signature(), Position: position(function.Position)}
+	}
+
+	for _, class := range pack.Classes.GetClassesSorted() {
+		if class.IsExternal() {
+			continue
+		}
+
+		exportedClass := &exportedClass{
+			Name:               class.name,
+			Abstract:           class.IsAbstract(),
+			AbstractInterfaces: class.abstractInterfaces,
+			Implements:         class.implements,
+			Interfaces:         class.interfaces,
+		}
+		for _, param := range class.typeParams {
+			exportedClass.TypeParams = append(exportedClass.TypeParams, &exportedTypeParam{Name: param.Name, Constraint: param.Constraint})
+		}
+		exportedClass.Position = position(class.Position())
+
+		if class.super != nil {
+			exportedClass.Super = classRef(class.super)
+			exportedClass.Super.TypeArgs = class.superTypeArgs
+		}
+
+		for _, vtable := range class.vtables {
+			exportedVTable := &exportedVTable{Name: vtable.name, Static: vtable.static, Slots: []*exportedSlot{}}
+			for _, function := range vtable.functions {
+				exportedVTable.Slots = append(exportedVTable.Slots, &exportedSlot{
+					Name:      function.name,
+					Signature: function.signature,
+					Abstract:  function.abstract,
+					Position:  position(function.method.Position),
+				})
+			}
+			exportedClass.VTables = append(exportedClass.VTables, exportedVTable)
+		}
+
+		for _, override := range class.overrides {
+			exportedOverride := &exportedOverride{
+				VTable:  override.overriddenVtable.name,
+				Owner:   classRef(class.VTableOwner(override.overriddenVtable)),
+				Methods: []*exportedMethod{},
+			}
+			for _, function := range override.functions {
+				exportedOverride.Methods = append(exportedOverride.Methods, method(function.method, function.name))
+			}
+			exportedClass.Overrides = append(exportedClass.Overrides, exportedOverride)
+		}
+
+		if class.constructor != nil {
+			exportedClass.Constructor = method(class.constructor, class.constructor.Name)
+		}
+
+		exported.Classes = append(exported.Classes, exportedClass)
+	}
+
+	return exported
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"github.com/tadnir/goop/package_parser"
+	"github.com/tadnir/goop/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the tests")
+
+// TestExportGolden pins the exported model and the diagrams of the fixture package testdata/export/shapes, which
+// inherits a class of testdata/export/base.
+func TestExportGolden(t *testing.T) {
+	dir, err := filepath.Abs(filepath.Join("testdata", "export"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPackages(dir, []string{"./shapes"}, package_parser.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format string
+		golden string
+	}{
+		{format: "json", golden: "classes.json"},
+		{format: "dot", golden: "classes.dot"},
+		{format: "mermaid", golden: "classes.mmd"},
+		{format: "plantuml", golden: "classes.puml"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var out bytes.Buffer
+			if test.format == "json" {
+				err = ExportJSON(&out, loaded)
+			} else {
+				err = ExportDiagram(&out, loaded, test.format)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The directories of the packages depend on the checkout
+			exported := strings.ReplaceAll(out.String(), filepath.ToSlash(dir), "$DIR")
+			golden := filepath.Join(dir, "golden", test.golden)
+			if *update {
+				if err := os.WriteFile(golden, []byte(exported), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if exported != string(expected) {
+				t.Errorf("the export differs from %s, rerun with -update if the change is intended:\n%s",
+					golden, utils.UnifiedDiff("a/"+test.golden, "b/"+test.golden, string(expected), exported))
+			}
+		})
+	}
+}
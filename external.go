@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"github.com/tadnir/goop/package_parser"
+	"github.com/tadnir/goop/utils"
+	"strings"
+)
+
+// ClassPackage is a package declaring classes inherited by the classes of the generated package.
+type ClassPackage struct {
+	// name qualifies the types of the package in the generated code, it's the name the inheriting file imports it as
+	name string
+	// aliased reports whether the inheriting file renames the import, so name isn't the package name
+	aliased bool
+	// path is the import path of the package
+	path string
+	// types are the exported types declared by the package
+	types []string
+}
+
+// classPackageKey identifies a package loaded for cross-package inheritance, by its import path and the name the
+// inheriting file imports it as, which qualifies its types.
+type classPackageKey struct {
+	path      string
+	qualifier string
+}
+
+// loadedPackages caches the classes of the packages loaded for cross-package inheritance.
+var loadedPackages = map[classPackageKey]*ClassesContainer{}
+
+// ResolveExternalClass returns the class of another package referred to as qualifiedName (e.g. core.Base) by the
+// struct structName, loading the classes of that package through the module.
+func ResolveExternalClass(packageData *package_parser.GoPackage, structName string, qualifiedName string) (*Class, error) {
+	qualifier, name, _ := strings.Cut(qualifiedName, ".")
+	file, err := packageData.GetStructFile(structName)
+	if err != nil {
+		return nil, err
+	}
+
+	listed, err := resolveImport(packageData.GetPath(), file.GetImports(), qualifier)
+	if err != nil {
+		return nil, fmt.Errorf("super of %s: %w", structName, err)
+	}
+
+	key := classPackageKey{path: listed.ImportPath, qualifier: qualifier}
+	classes, loaded := loadedPackages[key]
+	if !loaded {
+		classes, err = loadClassPackage(listed, qualifier)
+		if err != nil {
+			return nil, fmt.Errorf("loading the classes of %s: %w", listed.ImportPath, err)
+		}
+		loadedPackages[key] = classes
+	}
+
+	if !classes.HasClass(name) {
+		return nil, fmt.Errorf("super of %s: %s is not a class of %s", structName, name, listed.ImportPath)
+	}
+
+	class := classes.GetClass(name)
+	for ancestor := class; ancestor != nil; ancestor = ancestor.super {
+		if !ancestor.IsExtensible() {
+			return nil, fmt.Errorf("super of %s: %s must be marked //goop:extensible to be inherited from another package",
+				structName, ancestor.QualifiedName())
+		}
+
+		for _, vtable := range ancestor.vtables {
+			if vtable.static {
+				return nil, fmt.Errorf("super of %s: the static vtable %s of %s can't be inherited from another package",
+					structName, vtable.name, ancestor.QualifiedName())
+			}
+		}
+	}
+
+	return class, nil
+}
+
+// resolveImport finds the package imported as qualifier, either by renaming it or by its package name.
+func resolveImport(dir string, imports []*package_parser.Import, qualifier string) (*package_parser.ListedPackage, error) {
+	paths := []string{}
+	for _, imp := range imports {
+		if imp.Alias() == qualifier {
+			listedPackages, err := package_parser.ListPackages(dir, imp.Path())
+			if err != nil {
+				return nil, err
+			}
+
+			return listedPackages[0], nil
+		}
+
+		if imp.Alias() == "" {
+			paths = append(paths, imp.Path())
+		}
+	}
+
+	// The package name of an import which isn't renamed is only known by listing it
+	if len(paths) > 0 {
+		listedPackages, err := package_parser.ListPackages(dir, paths...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, listed := range listedPackages {
+			if listed.Name == qualifier {
+				return listed, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no imported package named %s", qualifier)
+}
+
+// loadClassPackage parses the package and builds its classes, qualifying the types in their methods by qualifier so
+// they can be used by the generated package.
+func loadClassPackage(listed *package_parser.ListedPackage, qualifier string) (*ClassesContainer, error) {
+	packageData, err := package_parser.ParsePackage(listed.Name, listed.Dir, package_parser.ParseOptions{
+		// The classes of the package may be declared by generated code, only the code goop generated is redundant
+		Generated: package_parser.SkipGoopGenerated,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := listed.Err(); err != nil {
+		return nil, err
+	}
+
+	classes, err := BuildClasses(packageData)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &ClassPackage{name: qualifier, aliased: qualifier != listed.Name, path: listed.ImportPath}
+	for _, st := range packageData.GetStructs() {
+		if utils.IsExported(st.Name) {
+			pkg.types = append(pkg.types, st.Name)
+		}
+	}
+	for _, in := range packageData.GetInterfaces() {
+		if utils.IsExported(in.Name) {
+			pkg.types = append(pkg.types, in.Name)
+		}
+	}
+	for _, namedType := range packageData.GetNamedTypes() {
+		if utils.IsExported(namedType.Name) {
+			pkg.types = append(pkg.types, namedType.Name)
+		}
+	}
+
+	for _, class := range classes.GetClassesSorted() {
+		if class.IsExternal() {
+			continue
+		}
+
+		class.pkg = pkg
+		if class.constructor != nil {
+			class.constructor = pkg.QualifyFunction(class.constructor)
+		}
+		for _, vtable := range class.vtables {
+			pkg.qualifyVFuncs(vtable.functions)
+		}
+		for _, override := range class.overrides {
+			pkg.qualifyVFuncs(override.functions)
+		}
+	}
+
+	return classes, nil
+}
+
+func (p *ClassPackage) qualifyVFuncs(functions []VFunc) {
+	for i := range functions {
+		functions[i].method = p.QualifyFunction(functions[i].method)
+		functions[i].signature = functions[i].method.Signature()
+	}
+}
+
+// QualifyFunction returns a copy of function whose parameters and results refer to the types of the package by
+// their qualified names.
+func (p *ClassPackage) QualifyFunction(function *package_parser.Function) *package_parser.Function {
+	return function.MapTypes(p.Qualify)
+}
+
+// Qualify prefixes the types of the package in the type expression with the package name.
+func (p *ClassPackage) Qualify(typeExpr string) string {
+	replacements := map[string]string{}
+	for _, name := range p.types {
+		replacements[name] = p.name + "." + name
+	}
+
+	return utils.ReplaceIdentifiers(typeExpr, replacements)
+}
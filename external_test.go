@@ -0,0 +1,170 @@
+package main
+
+import (
+	"github.com/tadnir/goop/package_parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModule writes the files of a module to a temporary directory, by their slash separated paths.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+const coreSource = `package core
+
+//goop:extensible
+type Base struct {
+	baseVtable ` + "`goop:\"vtable\"`" + `
+}
+
+func (b *Base) kindImpl() string { return "base" }
+`
+
+func TestExternalSuperImports(t *testing.T) {
+	tests := []struct {
+		name   string
+		module string
+		// service is the source of the package inheriting core.Base
+		service string
+		// contains are expected in the generated file, wantErr in the error instead
+		contains []string
+		wantErr  string
+	}{
+		{
+			name:   "renamed to avoid a conflict",
+			module: "example.com/renamed",
+			service: `package service
+
+import (
+	base "example.com/renamed/core"
+	core "example.com/renamed/other/core"
+)
+
+type Service struct {
+	base.Base ` + "`goop:\"super\"`" + `
+	other     core.Other
+}
+
+func (s *Service) kindImpl() string { return "service" }
+`,
+			contains: []string{`base "example.com/renamed/core"`, "base.BaseClass", "*base.Base"},
+		},
+		{
+			name:   "unrelated qualifier",
+			module: "example.com/unrelated",
+			service: `package service
+
+import "example.com/unrelated/core"
+
+var _ core.Base
+
+type Service struct {
+	foo.Base ` + "`goop:\"super\"`" + `
+}
+`,
+			wantErr: "no imported package named foo",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeModule(t, map[string]string{
+				"go.mod":              "module " + test.module + "\n\ngo 1.23\n",
+				"core/core.go":        coreSource,
+				"other/core/other.go": "package core\n\ntype Other struct{}\n",
+				"service/service.go":  test.service,
+			})
+
+			generated, _, err := GeneratePackages(dir, []string{"./service"}, package_parser.ParseOptions{}, false)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			source := generated[filepath.Join(dir, "service", "service_goop.go")]
+			for _, expected := range test.contains {
+				if !strings.Contains(source, expected) {
+					t.Errorf("generated file doesn't contain %q:\n%s", expected, source)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratedImports(t *testing.T) {
+	dir := goopModule(t, map[string]string{
+		// The package names differ from the last elements of their paths
+		"go-util/util.go": `package util
+
+type Options struct{}
+
+//goop:extensible
+type Base struct {
+	baseVtable ` + "`goop:\"vtable\"`" + `
+}
+
+func (b *Base) configureImpl(options Options) {}
+`,
+		"yaml.v3/yaml.go": "package yaml\n\ntype Node struct{}\n",
+		"service/service.go": `package service
+
+import (
+	"crypto/rand"
+	"example.com/test/go-util"
+)
+
+var _ = rand.Reader
+
+type Service struct {
+	util.Base     ` + "`goop:\"super\"`" + `
+	serviceVtable ` + "`goop:\"vtable\"`" + `
+}
+
+func (s *Service) configureImpl(options util.Options) {}
+`,
+		// The methods of another file refer to imports the file declaring the class doesn't import, or imports
+		// under the same name from another path
+		"service/run.go": `package service
+
+import (
+	"context"
+	"example.com/test/yaml.v3"
+	"math/rand"
+)
+
+func (s *Service) runImpl(ctx context.Context, node *yaml.Node) int {
+	return rand.Int()
+}
+`,
+	})
+
+	generated := generateModule(t, dir, false)
+	source := generated[filepath.Join(dir, "service", "service_goop.go")]
+	for _, expected := range []string{`"example.com/test/go-util"`, `"context"`, `"example.com/test/yaml.v3"`} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("generated file doesn't import %s:\n%s", expected, source)
+		}
+	}
+
+	goCommand(t, dir, "vet", "./...")
+}
@@ -2,7 +2,11 @@ package go_generator
 
 import (
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
 	"strings"
 )
 
@@ -14,16 +18,22 @@ type GoFileBuilder struct {
 	declaredVars    []*goVarDecl
 	functions       []*GoFunctionBuilder
 	structs         []*GoStructBuilder
+	interfaces      []*GoInterfaceBuilder
 	raw             []string
 }
 
 type goImport struct {
 	alias *string
 	path  string
+	// packageName is the name declared by the imported package, empty if it's unknown
+	packageName string
+	// optional imports are only kept when the generated code refers to them
+	optional bool
 }
 
 type goVarInitializer struct {
 	name        string
+	declType    string
 	initializer string
 }
 
@@ -36,25 +46,81 @@ func NewGoFileBuilder(generatedBy string, packageName string) *GoFileBuilder {
 		declaredVars:    []*goVarDecl{},
 		functions:       []*GoFunctionBuilder{},
 		structs:         []*GoStructBuilder{},
+		interfaces:      []*GoInterfaceBuilder{},
 		raw:             []string{},
 	}
 }
 
+// AddImport adds an import, which is kept even if it was added as optional before.
 func (b *GoFileBuilder) AddImport(path string) *GoFileBuilder {
-	b.imports = append(b.imports, &goImport{alias: nil, path: path})
+	if imp := b.findImport(nil, path); imp != nil {
+		imp.optional = false
+	} else {
+		b.imports = append(b.imports, &goImport{alias: nil, path: path})
+	}
 	return b
 }
 
+// AddAliasedImport adds an import renamed to alias, which is kept even if it was added as optional before.
 func (b *GoFileBuilder) AddAliasedImport(alias string, path string) *GoFileBuilder {
-	b.imports = append(b.imports, &goImport{alias: &alias, path: path})
+	if imp := b.findImport(&alias, path); imp != nil {
+		imp.optional = false
+	} else {
+		b.imports = append(b.imports, &goImport{alias: &alias, path: path})
+	}
+	return b
+}
+
+// AddOptionalImport adds an import that is kept only if the generated code refers to it, e.g. the imports of the
+// source files whose types may appear in copied signatures. An empty alias imports the package under packageName, the
+// name its package clause declares, which may differ from the last element of path, e.g. gopkg.in/yaml.v3.
+func (b *GoFileBuilder) AddOptionalImport(alias string, path string, packageName string) *GoFileBuilder {
+	var importAlias *string
+	if alias != "" {
+		importAlias = &alias
+	}
+
+	if imp := b.findImport(importAlias, path); imp != nil {
+		if imp.packageName == "" {
+			imp.packageName = packageName
+		}
+	} else {
+		b.imports = append(b.imports, &goImport{alias: importAlias, path: path, packageName: packageName, optional: true})
+	}
 	return b
 }
 
+// name is the name the import is referred to by, guessing it from the path when neither aliased nor known.
+func (imp *goImport) name() string {
+	if imp.alias != nil {
+		return *imp.alias
+	}
+	if imp.packageName != "" {
+		return imp.packageName
+	}
+
+	return path.Base(imp.path)
+}
+
+func (b *GoFileBuilder) findImport(alias *string, path string) *goImport {
+	for _, imp := range b.imports {
+		if imp.path == path && (imp.alias == nil) == (alias == nil) && (alias == nil || *imp.alias == *alias) {
+			return imp
+		}
+	}
+	return nil
+}
+
 func (b *GoFileBuilder) AddVarInitializer(name string, initializer string) *GoFileBuilder {
 	b.initializedVars = append(b.initializedVars, &goVarInitializer{name: name, initializer: initializer})
 	return b
 }
 
+func (b *GoFileBuilder) AddTypedVarInitializer(name string, declType string, initializer string) *GoFileBuilder {
+	b.initializedVars = append(b.initializedVars, &goVarInitializer{name: name, declType: declType, initializer: initializer})
+	return b
+}
+
 func (b *GoFileBuilder) AddVarDeclaration(name string, declType string) *GoFileBuilder {
 	b.declaredVars = append(b.declaredVars, &goVarDecl{name: name, declType: declType})
 	return b
@@ -70,21 +136,45 @@ func (b *GoFileBuilder) AddStruct(structBuilder *GoStructBuilder) *GoFileBuilder
 	return b
 }
 
+func (b *GoFileBuilder) AddInterface(interfaceBuilder *GoInterfaceBuilder) *GoFileBuilder {
+	b.interfaces = append(b.interfaces, interfaceBuilder)
+	return b
+}
+
 func (b *GoFileBuilder) AddRaw(raw string) *GoFileBuilder {
 	b.raw = append(b.raw, raw)
 	return b
 }
 
 func (b *GoFileBuilder) Build() (string, error) {
-	var sb strings.Builder
+	var header strings.Builder
 	// Header and Package
-	sb.WriteString(fmt.Sprintf("// Code generated by %v; DO NOT EDIT.\n", b.generatedBy))
-	sb.WriteString(fmt.Sprintf("package %v\n", b.packageName))
+	header.WriteString(fmt.Sprintf("// Code generated by %v; DO NOT EDIT.\n", b.generatedBy))
+	header.WriteString(fmt.Sprintf("package %v\n", b.packageName))
 
-	// Imports
-	if len(b.imports) > 0 {
+	body := b.buildBody()
+	used, err := usedNames(header.String() + body)
+	if err != nil {
+		return "", err
+	}
+
+	// Imports, where the first optional import of a name wins, e.g. the one of the file declaring the classes
+	imports := []*goImport{}
+	kept := map[string]bool{}
+	for _, imp := range b.imports {
+		if !imp.optional {
+			imports = append(imports, imp)
+		} else if name := imp.name(); used[name] && !b.importsName(name) && !kept[name] {
+			imports = append(imports, imp)
+			kept[name] = true
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header.String())
+	if len(imports) > 0 {
 		sb.WriteString("\nimport (\n")
-		for _, imp := range b.imports {
+		for _, imp := range imports {
 			if imp.alias != nil {
 				sb.WriteString(fmt.Sprintf("\t%v \"%v\"\n", *imp.alias, imp.path))
 			} else {
@@ -93,6 +183,49 @@ func (b *GoFileBuilder) Build() (string, error) {
 		}
 		sb.WriteString(")\n")
 	}
+	sb.WriteString(body)
+
+	out, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// importsName reports whether a required import is already referred to by name.
+func (b *GoFileBuilder) importsName(name string) bool {
+	for _, imp := range b.imports {
+		if !imp.optional && imp.name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// usedNames returns the identifiers qualifying selector expressions in source, which may refer to imports.
+func usedNames(source string) (map[string]bool, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", source, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	ast.Inspect(f, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	return used, nil
+}
+
+// buildBody builds the declarations of the file, following its imports.
+func (b *GoFileBuilder) buildBody() string {
+	var sb strings.Builder
 
 	// Global Vars
 	if len(b.declaredVars) > 0 || len(b.initializedVars) > 0 {
@@ -101,11 +234,23 @@ func (b *GoFileBuilder) Build() (string, error) {
 			sb.WriteString(fmt.Sprintf("\t%v\n", v.String()))
 		}
 		for _, v := range b.initializedVars {
-			sb.WriteString(fmt.Sprintf("\t%v = %v\n", v.name, v.initializer))
+			if v.declType != "" {
+				sb.WriteString(fmt.Sprintf("\t%v %v = %v\n", v.name, v.declType, v.initializer))
+			} else {
+				sb.WriteString(fmt.Sprintf("\t%v = %v\n", v.name, v.initializer))
+			}
 		}
 		sb.WriteString(")\n")
 	}
 
+	// Interfaces
+	if len(b.interfaces) > 0 {
+		for _, in := range b.interfaces {
+			sb.WriteString("\n")
+			sb.WriteString(in.Build())
+		}
+	}
+
 	// Structs
 	if len(b.structs) > 0 {
 		for _, st := range b.structs {
@@ -131,10 +276,5 @@ func (b *GoFileBuilder) Build() (string, error) {
 		sb.WriteString("\n")
 	}
 
-	out, err := format.Source([]byte(sb.String()))
-	if err != nil {
-		return "", err
-	}
-
-	return string(out), nil
+	return sb.String()
 }
@@ -8,11 +8,12 @@ import (
 )
 
 type GoFunctionBuilder struct {
-	name     string
-	params   []goVarDecl
-	retVals  []goVarDecl
-	receiver *goFuncReceiver
-	impl     string
+	name       string
+	typeParams []goVarDecl
+	params     []goVarDecl
+	retVals    []goVarDecl
+	receiver   *goFuncReceiver
+	impl       string
 }
 
 type goFuncReceiver struct {
@@ -35,6 +36,12 @@ func NewGoFunctionBuilder(name string) *GoFunctionBuilder {
 	}
 }
 
+// AddTypeParam adds a type parameter to the function, making it generic.
+func (b *GoFunctionBuilder) AddTypeParam(name string, constraint string) *GoFunctionBuilder {
+	b.typeParams = append(b.typeParams, goVarDecl{name, constraint})
+	return b
+}
+
 func (b *GoFunctionBuilder) AddParam(name string, paramType string) *GoFunctionBuilder {
 	b.params = append(b.params, goVarDecl{name, paramType})
 	return b
@@ -49,6 +56,11 @@ func (b *GoFunctionBuilder) AddReturnType(name string, retType string) *GoFuncti
 	return b
 }
 
+func (b *GoFunctionBuilder) AddUnnamedReturnType(retType string) *GoFunctionBuilder {
+	b.retVals = append(b.retVals, goVarDecl{"", retType})
+	return b
+}
+
 func (b *GoFunctionBuilder) SetReceiver(name string, receiverType string, isRef bool) *GoFunctionBuilder {
 	b.receiver = &goFuncReceiver{
 		name:         name,
@@ -87,5 +99,14 @@ func (b *GoFunctionBuilder) Build() string {
 		retVals += " "
 	}
 
-	return fmt.Sprintf("func %v%v(%v) %v{\n%v}\n", receiver, b.name, parameters, retVals, b.impl)
+	return fmt.Sprintf("func %v%v%v(%v) %v{\n%v}\n", receiver, b.name, typeParamsString(b.typeParams), parameters, retVals, b.impl)
+}
+
+// typeParamsString formats the declaration of type parameters, or an empty string when there are none.
+func typeParamsString(typeParams []goVarDecl) string {
+	if len(typeParams) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("[%v]", strings.Join(utils.Map(slices.Values(typeParams), goVarDecl.String), ", "))
 }
@@ -0,0 +1,58 @@
+package go_generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+type GoInterfaceBuilder struct {
+	name       string
+	doc        *string
+	typeParams []goVarDecl
+	methods    []goInterfaceMethod
+}
+
+type goInterfaceMethod struct {
+	name      string
+	signature string
+}
+
+func NewGoInterfaceBuilder(name string) *GoInterfaceBuilder {
+	return &GoInterfaceBuilder{name: name, methods: []goInterfaceMethod{}}
+}
+
+func (b *GoInterfaceBuilder) GetName() string {
+	return b.name
+}
+
+func (b *GoInterfaceBuilder) SetDoc(doc string) *GoInterfaceBuilder {
+	b.doc = &doc
+	return b
+}
+
+// AddTypeParam adds a type parameter to the interface, making it generic.
+func (b *GoInterfaceBuilder) AddTypeParam(name string, constraint string) *GoInterfaceBuilder {
+	b.typeParams = append(b.typeParams, goVarDecl{name, constraint})
+	return b
+}
+
+// AddMethod adds a method to the interface, signature is the method without its name, e.g. "(a int) string".
+func (b *GoInterfaceBuilder) AddMethod(name string, signature string) *GoInterfaceBuilder {
+	b.methods = append(b.methods, goInterfaceMethod{name: name, signature: signature})
+	return b
+}
+
+func (b *GoInterfaceBuilder) Build() string {
+	var sb strings.Builder
+	if b.doc != nil {
+		sb.WriteString(fmt.Sprintf("// %v\n", *b.doc))
+	}
+
+	sb.WriteString(fmt.Sprintf("type %v%v interface {\n", b.name, typeParamsString(b.typeParams)))
+	for _, m := range b.methods {
+		sb.WriteString(fmt.Sprintf("\t%v%v\n", m.name, m.signature))
+	}
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
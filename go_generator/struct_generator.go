@@ -6,9 +6,10 @@ import (
 )
 
 type GoStructBuilder struct {
-	name      string
-	vars      []goVarDecl
-	functions []*GoFunctionBuilder
+	name       string
+	typeParams []goVarDecl
+	vars       []goVarDecl
+	functions  []*GoFunctionBuilder
 }
 
 func NewGoStructBuilder(name string) *GoStructBuilder {
@@ -19,6 +20,12 @@ func (b *GoStructBuilder) GetName() string {
 	return b.name
 }
 
+// AddTypeParam adds a type parameter to the struct, making it generic.
+func (b *GoStructBuilder) AddTypeParam(name string, constraint string) *GoStructBuilder {
+	b.typeParams = append(b.typeParams, goVarDecl{name, constraint})
+	return b
+}
+
 func (b *GoStructBuilder) AddVar(name string, varType string) *GoStructBuilder {
 	b.vars = append(b.vars, goVarDecl{name, varType})
 	return b
@@ -32,7 +39,7 @@ func (b *GoStructBuilder) AddReceiverFunction(function *GoFunctionBuilder, recei
 
 func (b *GoStructBuilder) Build() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("type %v struct {\n", b.name))
+	sb.WriteString(fmt.Sprintf("type %v%v struct {\n", b.name, typeParamsString(b.typeParams)))
 	for _, v := range b.vars {
 		sb.WriteString(fmt.Sprintf("\t%v\n", v.String()))
 	}
@@ -8,5 +8,9 @@ type goVarDecl struct {
 }
 
 func (v goVarDecl) String() string {
+	if v.name == "" {
+		return v.declType
+	}
+
 	return fmt.Sprintf("%v %v", v.name, v.declType)
 }
@@ -1,132 +1,223 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/tadnir/goop/go_generator"
 	"github.com/tadnir/goop/package_parser"
+	"github.com/tadnir/goop/utils"
+	"go/build"
+	"go/types"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
-// Example data structure for the implement function
-//var (
-//	vtable = VTable{
-//		name: "aVtable",
-//		functions: []VFunc{
-//			{
-//				name:      "getName",
-//				signature: "func() string",
-//			},
-//		},
-//	}
-//	a = Class{
-//		name:      "A",
-//		super:     nil,
-//		vtable:    &vtable,
-//		overrides: nil,
-//	}
-//	b = Class{
-//		name:   "B",
-//		super:  &a,
-//		vtable: nil,
-//		overrides: []*Override{
-//			{
-//				overriddenVtable: &vtable,
-//				functions: []VFunc{
-//					{
-//						name:      "getName",
-//						signature: "func() string",
-//					},
-//				},
-//			},
-//		},
-//	}
-//	c = Class{
-//		name:   "C",
-//		super:  &b,
-//		vtable: nil,
-//		overrides: []*Override{
-//			{
-//				overriddenVtable: &vtable,
-//				functions: []VFunc{
-//					{
-//						name:      "getName",
-//						signature: "func() string",
-//					},
-//				},
-//			},
-//		},
-//	}
-//)
-
-func ImplementClass(file *go_generator.GoFileBuilder, class *Class) error {
-	if class.super != nil {
-		file.AddFunction(go_generator.NewGoFunctionBuilder("super").
-			SetReceiver("this", class.name, true).
-			AddReturnType("super", "*"+class.super.name).
-			AddImplLines(
-				"this.initClass()",
-				"return &this."+class.super.name,
-			),
-		)
-	}
-
-	if class.vtable != nil {
-		vtableStruct := go_generator.NewGoStructBuilder(class.vtable.name)
-		vtableStruct.AddVar(class.vtable.IsInitName(), "bool")
-		for _, function := range class.vtable.functions {
-			vtableStruct.AddVar(function.name, function.signature)
-		}
-		file.AddStruct(vtableStruct)
-	}
-
-	initFunc := go_generator.NewGoFunctionBuilder("initClass").
-		SetReceiver("this", class.name, true)
-	initExitConditions := []string{}
-	if class.vtable != nil {
-		initExitConditions = append(initExitConditions, "this."+class.vtable.IsInitName())
-	}
-	if class.overrides != nil {
-		for _, override := range class.overrides {
-			initExitConditions = append(initExitConditions, "this."+override.overriddenVtable.IsInitName())
-		}
-	}
-	if len(initExitConditions) > 0 {
-		initFunc.AddImplLines(
-			fmt.Sprintf("if %v {", strings.Join(initExitConditions, " && ")),
-			"return",
-			"}",
-			"",
-		)
-	}
-
-	if class.super != nil {
-		initFunc.AddImplLines(fmt.Sprintf("(&this.%v).initClass()", class.super.name), "")
-	}
-
-	if class.vtable != nil {
-		initFunc.AddImplLines(
-			fmt.Sprintf("// Initializing VTable '%v'", class.vtable.name),
-			fmt.Sprintf("this.%v = true", class.vtable.IsInitName()))
-		for _, function := range class.vtable.functions {
-			initFunc.AddImplLines(fmt.Sprintf("this.%v = this.%vImpl", function.name, function.name))
-		}
-	}
-
-	if class.overrides != nil {
-		for _, override := range class.overrides {
-			initFunc.AddImplLines(fmt.Sprintf("// Initializing Overrides for VTable '%v'", override.overriddenVtable.name))
-			for _, function := range override.functions {
-				initFunc.AddImplLines(fmt.Sprintf("this.%v = this.%vImpl", function.name, function.name))
-			}
-		}
-	}
-
-	file.AddFunction(initFunc)
+// ParseGoopTag splits a goop tag to its name and its comma separated options, e.g. goop:"vtable,static", ignoring the
+// spaces around them.
+func ParseGoopTag(tag string) (string, []string) {
+	parts := utils.Map(slices.Values(strings.Split(tag, ",")), strings.TrimSpace)
+	return parts[0], parts[1:]
+}
 
-	return nil
+// MarkerType is the type named by a marker field, e.g. goop:"abstract", either the type of the field or the element of
+// a zero-size array, e.g. handlerAbstract for "_ [0]handlerAbstract", which doesn't add to the size of the instances.
+func MarkerType(field *package_parser.FieldDeclaration) string {
+	if array, isArray := field.Type.(*package_parser.ArrayType); isArray && array.Len == "0" {
+		return array.Elem.String()
+	}
+
+	return field.VarType
+}
+
+// BuildClasses collects the classes declared in the package using goop tags, and assigns their virtual methods to vtables.
+func BuildClasses(packageData *package_parser.GoPackage) (*ClassesContainer, error) {
+	classes := NewClassesContainer()
+	for _, st := range packageData.GetStructs() {
+		for _, field := range st.Variables {
+			goopTag, isGoop := field.Tag.Lookup("goop")
+			if !isGoop {
+				continue
+			}
+
+			class := classes.GetClass(st.Name)
+			class.typeParams = st.TypeParams
+			class.declaration = st
+			class.extensible = slices.Contains(st.Directives, "goop:extensible")
+			goopTag, options := ParseGoopTag(goopTag)
+			switch goopTag {
+			case "super":
+				utils.Logf(utils.LogVerbose, "%s is child of %s!\n", st.Name, field.VarType)
+				if !IsStructType(field.ResolvedType) {
+					return nil, package_parser.Errorf(field.Position, "super %s of %s isn't a struct", field.VarType, st.Name)
+				}
+				superName, superTypeArgs := SplitTypeArgs(field.VarType)
+				if strings.Contains(superName, ".") && !classes.HasClass(superName) {
+					external, err := ResolveExternalClass(packageData, st.Name, superName)
+					if err != nil {
+						return nil, package_parser.Errorf(field.Position, "%v", err)
+					}
+					classes.AddClass(superName, external)
+				}
+				class.super = classes.GetClass(superName)
+				class.superTypeArgs = superTypeArgs
+			case "vtable":
+				utils.Logf(utils.LogVerbose, "%s has a vtable named %s!\n", st.Name, field.VarType)
+				vtableName, typeArgs := SplitTypeArgs(field.VarType)
+				vtable := &VTable{name: vtableName, functions: []VFunc{}, static: slices.Contains(options, "static")}
+				if len(typeArgs) > 0 {
+					// The vtable of a generic class is instantiated with the type parameters of the class
+					if !slices.Equal(typeArgs, utils.Map(slices.Values(st.TypeParams), func(p *package_parser.TypeParam) string { return p.Name })) {
+						return nil, package_parser.Errorf(field.Position, "vtable %s of %s must be instantiated with the type parameters of %s", field.VarType, st.Name, st.Name)
+					}
+					vtable.typeParams = st.TypeParams
+				}
+				if vtable.static && len(st.TypeParams) > 0 {
+					return nil, package_parser.Errorf(field.Position, "the generic class %s can't have the static vtable %s, as package level vtables can't be generic", st.Name, vtable.name)
+				}
+				class.vtables = append(class.vtables, vtable)
+			case "abstract":
+				utils.Logf(utils.LogVerbose, "%s is abstract with the methods of %s!\n", st.Name, MarkerType(field))
+				class.abstractInterfaces = append(class.abstractInterfaces, MarkerType(field))
+			case "implements":
+				utils.Logf(utils.LogVerbose, "%s implements %s!\n", st.Name, MarkerType(field))
+				class.implements = append(class.implements, MarkerType(field))
+			case "interface":
+				utils.Logf(utils.LogVerbose, "%s generates the interface %s!\n", st.Name, MarkerType(field))
+				class.interfaces = append(class.interfaces, MarkerType(field))
+			default:
+				utils.Warnf("%s: unknown goop tag '%s' of %s\n", field.TagPosition, goopTag, st.Name)
+			}
+		}
+	}
+
+	for _, cl := range classes.GetClassesSorted() {
+		if cl.IsExternal() {
+			// Classes of other packages were already built when loading them
+			continue
+		}
+
+		for _, interfaceName := range cl.abstractInterfaces {
+			interfaceName, typeArgs := SplitTypeArgs(interfaceName)
+			abstractInterface, err := packageData.GetInterface(interfaceName)
+			if err != nil {
+				return nil, package_parser.Errorf(cl.Position(), "abstract methods of %s: %v", cl.name, err)
+			}
+
+			methods, err := packageData.GetMethodSet(interfaceName)
+			if err != nil {
+				return nil, package_parser.Errorf(cl.Position(), "abstract methods of %s: %v", cl.name, err)
+			}
+
+			for _, method := range methods {
+				method = method.MapTypes(func(typeExpr string) string {
+					return SubstituteTypeParams(typeExpr, abstractInterface.TypeParams, typeArgs)
+				})
+				if err := cl.RegisterAbstract(method); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, recvFunc := range packageData.GetReceiverFunctions(cl.name) {
+			if IsConstructor(recvFunc) {
+				cl.constructor = recvFunc
+				continue
+			}
+
+			if IsVirtualMethod(recvFunc) {
+				// check if any of the parents has this function in it's vtable, if so create an override
+				// if not, add it to the vtable of the struct it's bound to, or to its first vtable
+				// otherwise fail
+				vtable, err := cl.ChooseVTable(recvFunc)
+				if err != nil {
+					return nil, err
+				}
+
+				utils.Logf(utils.LogVerbose, "Overriden %s for %s\n", recvFunc.Name, cl.name)
+				if err := cl.RegisterVirtual(recvFunc, vtable); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	var errs package_parser.ErrorList
+	for _, cl := range classes.GetClassesSorted() {
+		if cl.IsExternal() {
+			continue
+		}
+
+		if cl.constructor != nil && cl.super != nil {
+			if superConstructor := cl.super.Constructor(); superConstructor != nil && len(superConstructor.ArgumentTypes) > 0 && !cl.CallsSuperConstruct() {
+				errs.Add(package_parser.Errorf(cl.constructor.Position,
+					"construct of %s must call superConstruct, as the construct method of %s takes arguments",
+					cl.name, cl.super.ConstructorClass().name))
+			}
+		}
+
+		if cl.IsExtensible() {
+			if !utils.IsExported(cl.name) {
+				errs.Add(package_parser.Errorf(cl.Position(), "the extensible class %s must be exported", cl.name))
+			}
+
+			for _, vtable := range cl.vtables {
+				if vtable.static {
+					errs.Add(package_parser.Errorf(cl.Position(),
+						"the extensible class %s can't have the static vtable %s, which can't be inherited from another package",
+						cl.name, vtable.name))
+				}
+			}
+
+			if cl.super != nil && !cl.super.IsExtensible() {
+				errs.Add(package_parser.Errorf(cl.Position(),
+					"the extensible class %s inherits %s, which must be marked //goop:extensible as well",
+					cl.name, cl.super.QualifiedName()))
+			}
+		}
+
+		if cl.IsGeneric() {
+			for _, ancestor := range cl.Ancestry() {
+				for _, vtable := range ancestor.vtables {
+					if vtable.static && ancestor != cl {
+						errs.Add(package_parser.Errorf(cl.Position(),
+							"the generic class %s can't inherit the static vtable %s of %s, as package level vtables can't be generic",
+							cl.name, vtable.name, ancestor.name))
+					}
+				}
+			}
+		}
+
+		if cl.IsAbstract() {
+			continue
+		}
+
+		for _, missing := range cl.MissingAbstracts() {
+			errs.Add(package_parser.Errorf(cl.Position(),
+				"class %s does not implement the abstract method %s, implement it or mark %s abstract with a goop:\"abstract\" field",
+				cl.name, missing, cl.name))
+		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return classes, nil
+}
+
+// IsStructType reports whether a type-checked type is a struct, types which weren't resolved may be anything.
+func IsStructType(resolved types.Type) bool {
+	if resolved == nil || resolved == types.Typ[types.Invalid] {
+		return true
+	}
+
+	_, isStruct := resolved.Underlying().(*types.Struct)
+	return isStruct
 }
 
 func getParameters() (fileName string, packageName string, packagePath string) {
@@ -152,79 +243,483 @@ func getParameters() (fileName string, packageName string, packagePath string) {
 	return
 }
 
-func main() {
-	inputFile, packageName, packagePath := getParameters()
-	fmt.Printf("Gooping...\n")
+// importNames are the names declared by the packages imported by the generated packages, by their import paths.
+var importNames = map[string]string{}
+
+// resolveImportNames lists the packages imported by the files whose names aren't known yet, as the generated code
+// refers to them by the names they declare, which may differ from the last elements of their paths, e.g. go-util
+// declaring package util.
+func resolveImportNames(dir string, files []*package_parser.GoFile) error {
+	paths := []string{}
+	for _, file := range files {
+		for _, imp := range file.GetImports() {
+			if _, known := importNames[imp.Path()]; !known && imp.Alias() == "" && imp.Path() != "C" && !slices.Contains(paths, imp.Path()) {
+				paths = append(paths, imp.Path())
+			}
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
 
-	packageData, err := package_parser.ParsePackage(packageName, packagePath, true)
+	listedPackages, err := package_parser.ListPackages(dir, paths...)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	classes := NewClassesContainer()
-	for _, st := range packageData.GetStructs() {
-		for _, field := range st.Variables {
-			goopTag, isGoop := field.Tag.Lookup("goop")
-			if !isGoop {
+	for _, listed := range listedPackages {
+		importNames[listed.ImportPath] = listed.Name
+	}
+
+	return nil
+}
+
+// GenerateFile implements the classes declared by the file named fileName, returning the sources of the generated
+// files by their names. The classes of test files are generated to test files, as they may depend on other tests.
+func GenerateFile(packageData *package_parser.GoPackage, classes *ClassesContainer, fileName string, generateTests bool) (map[string]string, error) {
+	fileData, err := packageData.GetFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file := go_generator.NewGoFileBuilder("goop", packageData.GetName())
+	testFile := go_generator.NewGoFileBuilder("goop", packageData.GetName())
+	// The generated code copies signatures of methods which may be declared by any file of the package, and refer to
+	// its imports. The imports of the file come first, winning over the other files' imports of the same name.
+	files := []*package_parser.GoFile{fileData}
+	for _, other := range packageData.GetFiles() {
+		if other != fileData {
+			files = append(files, other)
+		}
+	}
+	if err := resolveImportNames(packageData.GetPath(), files); err != nil {
+		return nil, err
+	}
+	for _, source := range files {
+		for _, imp := range source.GetImports() {
+			if imp.Alias() == "_" || imp.Alias() == "." || imp.Path() == "C" {
 				continue
 			}
 
-			class := classes.GetClass(st.Name)
-			switch goopTag {
-			case "super":
-				fmt.Printf("%s is child of %s!\n", st.Name, field.VarType)
-				class.super = classes.GetClass(field.VarType)
-			case "vtable":
-				fmt.Printf("%s has a vtable named %s!\n", st.Name, field.VarType)
-				class.vtable = &VTable{name: field.VarType, functions: []VFunc{}}
-			default:
-				fmt.Printf("Unknown goop tag '%s'!\n", goopTag)
+			file.AddOptionalImport(imp.Alias(), imp.Path(), importNames[imp.Path()])
+		}
+	}
+	for _, st := range fileData.GetStructs() {
+		if !classes.HasClass(st.Name) {
+			continue
+		}
+
+		utils.Logf(utils.LogVerbose, "Implementing class %s...\n", st.Name)
+		err = ImplementClass(file, classes.GetClass(st.Name))
+		if err != nil {
+			return nil, err
+		}
+
+		ImplementClassTests(testFile, classes.GetClass(st.Name))
+	}
+
+	source, err := file.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := strings.TrimSuffix(fileName, ".go")
+	isTestFile := strings.HasSuffix(fileName, "_test.go")
+	if isTestFile {
+		return map[string]string{baseName + "_goop_test.go": source}, nil
+	}
+
+	generated := map[string]string{baseName + "_goop.go": source}
+	if generateTests {
+		testSource, err := testFile.Build()
+		if err != nil {
+			return nil, err
+		}
+
+		generated[baseName+"_goop_test.go"] = testSource
+	}
+
+	return generated, nil
+}
+
+// ClassFiles returns the names of the package's files declaring classes.
+func ClassFiles(packageData *package_parser.GoPackage, classes *ClassesContainer) []string {
+	fileNames := []string{}
+	for _, file := range packageData.GetFiles() {
+		for _, st := range file.GetStructs() {
+			if classes.HasClass(st.Name) {
+				fileNames = append(fileNames, file.GetName())
+				break
 			}
 		}
 	}
 
-	for _, cl := range classes.GetClassesSorted() {
-		for _, recvFunc := range packageData.GetReceiverFunctions(cl.name) {
-			if IsVirtualMethod(recvFunc) {
-				// check if any of the parents has this function in it's vtable, if so create an override
-				// if not, if there's a vtable for the struct add it to there
-				// otherwise panic
-				if vtable := cl.ChooseVTable(recvFunc); vtable != nil {
-					fmt.Printf("Overriden %s for %s\n", recvFunc.Name, cl.name)
-					cl.RegisterVirtual(recvFunc, vtable)
-				} else {
-					panic(fmt.Sprintf("Can't find vtable for %s in %s", recvFunc.Name, cl.name))
-				}
+	return fileNames
+}
+
+// LoadedPackage is a parsed package and the classes it declares.
+type LoadedPackage struct {
+	Listed  *package_parser.ListedPackage
+	Data    *package_parser.GoPackage
+	Classes *ClassesContainer
+}
+
+// LoadPackages parses the packages matching the patterns (e.g. ./...) once each, including their test files, and
+// builds their classes. External test packages are loaded after the package they test.
+func LoadPackages(dir string, patterns []string, options package_parser.ParseOptions) ([]*LoadedPackage, error) {
+	listedPackages, err := package_parser.ListPackages(dir, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := []*LoadedPackage{}
+	options.IncludeTests = true
+	for _, listed := range listedPackages {
+		utils.Logf(utils.LogNormal, "Gooping package %s...\n", listed.ImportPath)
+		packageData, err := package_parser.ParsePackage(listed.Name, listed.Dir, options)
+		if err != nil {
+			return nil, err
+		}
+
+		// The errors in the source are reported by the parser, the remaining ones only by go list
+		if err := listed.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, pack := range []*package_parser.GoPackage{packageData, packageData.ExternalTests()} {
+			if pack == nil {
+				continue
 			}
+
+			classes, err := BuildClasses(pack)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", listed.ImportPath, err)
+			}
+
+			loaded = append(loaded, &LoadedPackage{Listed: listed, Data: pack, Classes: classes})
 		}
 	}
 
-	fmt.Printf("%+v", classes)
+	return loaded, nil
+}
 
-	fileData, err := packageData.GetFile(inputFile)
+// GeneratePackages generates the files declaring classes in the packages matching the patterns, including their
+// test files, and returns the files goop generated in the packages before which aren't generated anymore.
+func GeneratePackages(dir string, patterns []string, options package_parser.ParseOptions, generateTests bool) (map[string]string, []string, error) {
+	loaded, err := LoadPackages(dir, patterns, options)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 
-	file := go_generator.NewGoFileBuilder("goop", packageData.GetName())
-	for _, st := range fileData.GetStructs() {
-		fmt.Printf("Implementing class %s...\n", st.Name)
-		err = ImplementClass(file, classes.GetClass(st.Name))
+	generated := map[string]string{}
+	for _, pack := range loaded {
+		for _, fileName := range ClassFiles(pack.Data, pack.Classes) {
+			files, err := GenerateFile(pack.Data, pack.Classes, fileName, generateTests)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", pack.Listed.ImportPath, err)
+			}
+
+			for name, source := range files {
+				generated[filepath.Join(pack.Listed.Dir, name)] = source
+			}
+		}
+	}
+
+	existing := []string{}
+	for _, pack := range loaded {
+		for _, pattern := range []string{"*_goop.go", "*_goop_test.go"} {
+			// The pattern is valid, and Glob only fails on invalid patterns
+			matches, _ := filepath.Glob(filepath.Join(pack.Listed.Dir, pattern))
+			existing = append(existing, matches...)
+		}
+	}
+
+	stale, err := staleGenerated(existing, generated, generateTests)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return generated, stale, nil
+}
+
+// staleGenerated returns the files among paths which goop generated but doesn't generate anymore, e.g. as their source
+// file no longer declares classes or was deleted. The initialization tests of source files are only generated with
+// generateTests, so without it they're only stale once their source file was deleted.
+func staleGenerated(paths []string, generated map[string]string, generateTests bool) ([]string, error) {
+	stale := []string{}
+	for _, path := range paths {
+		if _, ok := generated[path]; ok || slices.Contains(stale, path) {
+			continue
+		}
+
+		// The classes of test files, e.g. of mock_test.go, are generated to mock_test_goop_test.go regardless
+		if base, isTest := strings.CutSuffix(path, "_goop_test.go"); isTest && !generateTests && !strings.HasSuffix(base, "_test") {
+			if _, err := os.Stat(base + ".go"); err == nil {
+				continue
+			}
+		}
+
+		generatedLine, err := package_parser.GeneratedBy(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// Only the files marked as generated by goop are removed, not every file named like them
+		if strings.HasPrefix(generatedLine, "// Code generated by goop") {
+			stale = append(stale, path)
+		}
+	}
+
+	slices.Sort(stale)
+	return stale, nil
+}
+
+// writeGenerated writes the generated files by their paths, and removes the stale ones.
+func writeGenerated(generated map[string]string, stale []string) {
+	for _, path := range slices.Sorted(maps.Keys(generated)) {
+		utils.Logf(utils.LogDebug, "%s\n", generated[path])
+		if err := os.WriteFile(path, []byte(generated[path]), 0777); err != nil {
+			log.Fatal(err)
+		}
+		utils.Logf(utils.LogNormal, "Generated %s\n", path)
+	}
+
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			log.Fatal(err)
+		}
+		utils.Logf(utils.LogNormal, "Removed %s\n", path)
+	}
+}
+
+// CheckGenerated compares the generated files with the ones on disk, printing the unified diff of the out of date ones
+// and of the stale ones, which would be removed, and returns their number.
+func CheckGenerated(generated map[string]string, stale []string) (int, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return 0, err
+	}
+
+	outdated := 0
+	for _, path := range slices.Sorted(maps.Keys(generated)) {
+		name := path
+		if relative, err := filepath.Rel(dir, path); err == nil {
+			name = relative
+		}
+
+		oldName, onDisk := "a/"+name, ""
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			oldName = "/dev/null"
+		} else if err != nil {
+			return 0, err
+		} else {
+			onDisk = string(content)
+		}
+
+		if diff := utils.UnifiedDiff(oldName, "b/"+name, onDisk, generated[path]); diff != "" {
+			fmt.Print(diff)
+			outdated++
+		}
+	}
+
+	for _, path := range stale {
+		name := path
+		if relative, err := filepath.Rel(dir, path); err == nil {
+			name = relative
+		}
+
+		content, err := os.ReadFile(path)
 		if err != nil {
-			panic(err)
+			return 0, err
 		}
+
+		fmt.Printf("%s would be removed\n", name)
+		fmt.Print(utils.UnifiedDiff("a/"+name, "/dev/null", string(content), ""))
+		outdated++
 	}
 
-	source, err := file.Build()
+	return outdated, nil
+}
+
+// generateGoFile generates the classes of $GOFILE, as run by go:generate, and returns the files goop generated for it
+// before which aren't generated anymore.
+func generateGoFile(options package_parser.ParseOptions, generateTests bool) (map[string]string, []string) {
+	inputFile, packageName, packagePath := getParameters()
+	utils.Logf(utils.LogNormal, "Gooping...\n")
+
+	// go generate names the external test package of the directory "<name>_test"
+	isTestFile := strings.HasSuffix(inputFile, "_test.go")
+	parsedPackage := packageName
+	if isTestFile {
+		parsedPackage = strings.TrimSuffix(packageName, "_test")
+	}
+
+	options.IncludeTests = isTestFile
+	packageData, err := package_parser.ParsePackage(parsedPackage, packagePath, options)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if packageData.GetName() != packageName {
+		if packageData = packageData.ExternalTests(); packageData == nil {
+			log.Fatalf("package %s not found in %s", packageName, packagePath)
+		}
+	}
+
+	classes, err := BuildClasses(packageData)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
 
-	println(source)
+	utils.Logf(utils.LogDebug, "%+v", classes)
 
-	outputFile := inputFile[:len(inputFile)-len(".go")] + "_goop.go"
-	err = os.WriteFile(filepath.Join(packagePath, outputFile), []byte(source), 0777)
+	files, err := GenerateFile(packageData, classes, inputFile, generateTests)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
+	}
+
+	if generateTests && isTestFile {
+		utils.Logf(utils.LogVerbose, "Skipping the initialization tests of %s, which is a test file.\n", inputFile)
+	}
+
+	generated := map[string]string{}
+	for name, source := range files {
+		generated[filepath.Join(packagePath, name)] = source
 	}
+
+	baseName := filepath.Join(packagePath, strings.TrimSuffix(inputFile, ".go"))
+	stale, err := staleGenerated([]string{baseName + "_goop.go", baseName + "_goop_test.go"}, generated, generateTests)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return generated, stale
+}
+
+// goFlagsTags returns the build tags set by $GOFLAGS, e.g. "a,b" for GOFLAGS=-tags=a,b, which go list honours.
+func goFlagsTags() string {
+	tags := ""
+	for _, goFlag := range strings.Fields(os.Getenv("GOFLAGS")) {
+		if value, ok := strings.CutPrefix(strings.TrimLeft(goFlag, "-"), "tags="); ok {
+			tags = value
+		}
+	}
+
+	return tags
+}
+
+func main() {
+	generateTests := flag.Bool("tests", false, "also generate tests checking the class initialization under the race detector")
+	skipGenerated := flag.String("skip-generated", "all", "the generated files of the package to skip: \"all\", or only the ones generated by \"goop\"")
+	logLevel := flag.String("log", os.Getenv("GOOP_LOG"), "how much to report: quiet (default), normal, verbose or debug, also set by $GOOP_LOG")
+	format := flag.String("format", "json", "the format of goop export: json, or a class diagram as dot, mermaid or plantuml")
+	tags := flag.String("tags", goFlagsTags(), "comma separated build tags to consider satisfied, the -tags of $GOFLAGS by default")
+	typeCheck := flag.Bool("typecheck", false, "type check the package, verifying supers are structs and overrides match the overridden signatures exactly")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: goop [flags] [check | export] [flags] [packages]\n\n"+
+			"Without packages, generates the classes of $GOFILE, as run by go:generate.\n"+
+			"With packages (e.g. ./...), generates the classes of every file of the packages.\n"+
+			"check writes nothing, printing the diff of the generated files which are out of date and failing if any.\n"+
+			"export writes the classes of the packages (. by default) to stdout in the -format.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.CommandLine.Parse(os.Args[1:])
+
+	// "goop check" generates in memory, and reports the generated files on disk which are out of date
+	// "goop export" writes the classes of the packages instead of generating them
+	// The command is the first argument following the flags, which may also follow it, e.g. goop -tests check ./...
+	command := "generate"
+	if flag.Arg(0) == "check" || flag.Arg(0) == "export" {
+		command = flag.Arg(0)
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+
+	if *logLevel != "" {
+		level, err := utils.ParseLogLevel(*logLevel)
+		if err != nil {
+			log.Fatal(err)
+		}
+		utils.SetLogLevel(level)
+	}
+
+	// The build tags apply to every parsed package and to go list, including the packages of inherited classes
+	if *tags != "" {
+		build.Default.BuildTags = strings.Split(*tags, ",")
+	}
+
+	generatedFiles := package_parser.SkipGenerated
+	switch *skipGenerated {
+	case "all":
+	case "goop":
+		generatedFiles = package_parser.SkipGoopGenerated
+	default:
+		log.Fatalf("-skip-generated must be \"all\" or \"goop\", got %q", *skipGenerated)
+	}
+	options := package_parser.ParseOptions{Generated: generatedFiles, TypeCheck: *typeCheck}
+
+	if command == "export" {
+		dir, err := os.Getwd()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		patterns := flag.Args()
+		if len(patterns) == 0 {
+			patterns = []string{"."}
+		}
+
+		loaded, err := LoadPackages(dir, patterns, options)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		switch *format {
+		case "json":
+			err = ExportJSON(os.Stdout, loaded)
+		case "dot", "mermaid", "plantuml":
+			err = ExportDiagram(os.Stdout, loaded, *format)
+		default:
+			log.Fatalf("unknown export format %q", *format)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var generated map[string]string
+	var stale []string
+	if flag.NArg() > 0 {
+		dir, err := os.Getwd()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		utils.Logf(utils.LogNormal, "Gooping...\n")
+		generated, stale, err = GeneratePackages(dir, flag.Args(), options, *generateTests)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		generated, stale = generateGoFile(options, *generateTests)
+	}
+
+	if command == "check" {
+		outdated, err := CheckGenerated(generated, stale)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if outdated > 0 {
+			fmt.Fprintf(os.Stderr, "%d generated files are out of date, run go generate\n", outdated)
+			os.Exit(1)
+		}
+		return
+	}
+
+	writeGenerated(generated, stale)
 }
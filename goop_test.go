@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"github.com/tadnir/goop/package_parser"
+	"github.com/tadnir/goop/utils"
+	"go/build"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// goopModule writes the files of the module example.com/test, which requires the goop of the checkout for the
+// generated code to build against its rtti package.
+func goopModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files["go.mod"] = fmt.Sprintf("module example.com/test\n\ngo 1.23\n\nrequire github.com/tadnir/goop v1.0.0\n\n"+
+		"replace github.com/tadnir/goop => %s\n", root)
+	return writeModule(t, files)
+}
+
+// generateModule generates every package of the module in dir, writes the generated files and returns them.
+func generateModule(t *testing.T, dir string, generateTests bool) map[string]string {
+	t.Helper()
+	generated, _, err := GeneratePackages(dir, []string{"./..."}, package_parser.ParseOptions{}, generateTests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, source := range generated {
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return generated
+}
+
+// goCommand runs go with args in dir, failing the test with its output if it fails.
+func goCommand(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+
+	return string(out)
+}
+
+func TestLoadPackagesSourceErrors(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"go.mod":       "module example.com/broken\n\ngo 1.23\n",
+		"pack/pack.go": "package pack\n\ntype A struct{}\n",
+		"pack/bad.go":  "type B struct{}\n",
+	})
+
+	_, err := LoadPackages(dir, []string{"./..."}, package_parser.ParseOptions{})
+	expected := filepath.Join(dir, "pack", "bad.go") + ":1:1: expected 'package'"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected an error containing %q, got %v", expected, err)
+	}
+}
+
+func TestGeneratePackagesStaleFiles(t *testing.T) {
+	const goopHeader = "// Code generated by goop; DO NOT EDIT.\npackage pack\n"
+	files := map[string]string{
+		"go.mod": "module example.com/stale\n\ngo 1.23\n",
+		"pack/shape.go": "package pack\n\ntype Shape struct {\n\tshapeVtable `goop:\"vtable\"`\n}\n\n" +
+			"func (s *Shape) areaImpl() int { return 0 }\n",
+		"pack/shape_goop.go":      goopHeader,
+		"pack/shape_goop_test.go": goopHeader,
+		// plain.go and mock_test.go no longer declare classes, and deleted.go was deleted
+		"pack/plain.go":               "package pack\n",
+		"pack/plain_goop.go":          goopHeader,
+		"pack/plain_goop_test.go":     goopHeader,
+		"pack/mock_test.go":           "package pack\n",
+		"pack/mock_test_goop_test.go": goopHeader,
+		"pack/deleted_goop.go":        goopHeader,
+		"pack/deleted_goop_test.go":   goopHeader,
+		"pack/manual_goop.go":         "package pack\n",
+	}
+
+	tests := []struct {
+		generateTests bool
+		stale         []string
+	}{
+		{
+			// The initialization tests may have been generated by an earlier run with -tests
+			generateTests: false,
+			stale:         []string{"deleted_goop.go", "deleted_goop_test.go", "mock_test_goop_test.go", "plain_goop.go"},
+		},
+		{
+			generateTests: true,
+			stale:         []string{"deleted_goop.go", "deleted_goop_test.go", "mock_test_goop_test.go", "plain_goop.go", "plain_goop_test.go"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("tests=%v", test.generateTests), func(t *testing.T) {
+			dir := writeModule(t, files)
+			generated, stale, err := GeneratePackages(dir, []string{"./..."}, package_parser.ParseOptions{Generated: package_parser.SkipGenerated}, test.generateTests)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := generated[filepath.Join(dir, "pack", "shape_goop.go")]; !ok {
+				t.Errorf("shape_goop.go wasn't generated")
+			}
+
+			expected := utils.Map(slices.Values(test.stale), func(name string) string { return filepath.Join(dir, "pack", name) })
+			if !slices.Equal(stale, expected) {
+				t.Errorf("expected the stale files %v, got %v", expected, stale)
+			}
+		})
+	}
+}
+
+func TestGoFlagsTags(t *testing.T) {
+	tests := []struct {
+		goFlags  string
+		expected string
+	}{
+		{goFlags: "", expected: ""},
+		{goFlags: "-mod=mod", expected: ""},
+		{goFlags: "-tags=mytag", expected: "mytag"},
+		{goFlags: "-mod=mod --tags=a,b -race", expected: "a,b"},
+		{goFlags: "-tags=a -tags=b", expected: "b"},
+	}
+
+	for _, test := range tests {
+		t.Setenv("GOFLAGS", test.goFlags)
+		if tags := goFlagsTags(); tags != test.expected {
+			t.Errorf("GOFLAGS=%q: expected the tags %q, got %q", test.goFlags, test.expected, tags)
+		}
+	}
+}
+
+func TestGeneratePackagesBuildTags(t *testing.T) {
+	dir := goopModule(t, map[string]string{
+		// Every file of the package is excluded without the tag, which go list reports as an error
+		"tagged/t.go": "//go:build mytag\n\npackage tagged\n\ntype T struct {\n\ttVtable `goop:\"vtable\"`\n}\n\n" +
+			"func (x *T) runImpl() {}\n",
+	})
+
+	defaultTags := build.Default.BuildTags
+	t.Cleanup(func() { build.Default.BuildTags = defaultTags })
+	build.Default.BuildTags = []string{"mytag"}
+
+	generated := generateModule(t, dir, false)
+	if _, ok := generated[filepath.Join(dir, "tagged", "t_goop.go")]; !ok {
+		t.Fatalf("the tagged file wasn't generated, got %v", slices.Collect(maps.Keys(generated)))
+	}
+
+	goCommand(t, dir, "vet", "-tags=mytag", "./...")
+}
+
+func TestParseGoopTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		name    string
+		options []string
+	}{
+		{tag: "super", name: "super", options: []string{}},
+		{tag: "vtable,static", name: "vtable", options: []string{"static"}},
+		{tag: "vtable, static", name: "vtable", options: []string{"static"}},
+		{tag: " vtable , static ", name: "vtable", options: []string{"static"}},
+	}
+
+	for _, test := range tests {
+		name, options := ParseGoopTag(test.tag)
+		if name != test.name || !slices.Equal(options, test.options) {
+			t.Errorf("ParseGoopTag(%q): expected %q %q, got %q %q", test.tag, test.name, test.options, name, options)
+		}
+	}
+}
+
+// classTest is a package whose classes fail to build with err, "<file>:<line>:<column>: <message>" relative to the
+// directory of the package, or whose generated code builds when err is empty.
+type classTest struct {
+	name  string
+	files map[string]string
+	err   string
+}
+
+func runClassTests(t *testing.T, tests []classTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := goopModule(t, maps.Clone(test.files))
+			if test.err == "" {
+				generateModule(t, dir, false)
+				goCommand(t, dir, "vet", "./...")
+				return
+			}
+
+			_, _, err := GeneratePackages(dir, []string{"./..."}, package_parser.ParseOptions{}, false)
+			expected := filepath.Join(dir, test.err)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Fatalf("expected an error containing %q, got %v", expected, err)
+			}
+		})
+	}
+}
+
+const abstractBaseSource = `package shapes
+
+type Shape struct {
+	shapeVtable ` + "`goop:\"vtable\"`" + `
+	_           [0]shapeAbstract ` + "`goop:\"abstract\"`" + `
+}
+
+type shapeAbstract interface {
+	area() int
+}
+`
+
+func TestAbstractMethods(t *testing.T) {
+	runClassTests(t, []classTest{
+		{
+			name: "concrete class missing an abstract method",
+			files: map[string]string{
+				"shape.go": abstractBaseSource,
+				"rect.go": `package shapes
+
+type Rect struct {
+	Shape ` + "`goop:\"super\"`" + `
+}
+`,
+			},
+			err: `rect.go:3:6: class Rect does not implement the abstract method Shape.area, implement it or mark Rect abstract with a goop:"abstract" field`,
+		},
+		{
+			name: "intermediate abstract class",
+			files: map[string]string{
+				"shape.go": abstractBaseSource,
+				"named.go": `package shapes
+
+type Named struct {
+	Shape       ` + "`goop:\"super\"`" + `
+	namedVtable ` + "`goop:\"vtable\"`" + `
+	_           [0]namedAbstract ` + "`goop:\"abstract\"`" + `
+}
+
+type namedAbstract interface {
+	name() string
+}
+
+type Square struct {
+	Named ` + "`goop:\"super\"`" + `
+}
+
+func (s *Square) areaImpl() int { return 4 }
+
+func (s *Square) nameImpl() string { return "square" }
+`,
+			},
+		},
+		{
+			name: "concrete class missing the abstract method of an abstract ancestor",
+			files: map[string]string{
+				"shape.go": abstractBaseSource,
+				"named.go": `package shapes
+
+type Named struct {
+	Shape       ` + "`goop:\"super\"`" + `
+	namedVtable ` + "`goop:\"vtable\"`" + `
+	_           [0]namedAbstract ` + "`goop:\"abstract\"`" + `
+}
+
+type namedAbstract interface {
+	name() string
+}
+
+type Square struct {
+	Named ` + "`goop:\"super\"`" + `
+}
+
+func (s *Square) nameImpl() string { return "square" }
+`,
+			},
+			err: `named.go:13:6: class Square does not implement the abstract method Shape.area`,
+		},
+		{
+			name: "abstract class implementing its abstract method",
+			files: map[string]string{
+				"shape.go": abstractBaseSource + `
+func (s *Shape) areaImpl() int { return 0 }
+`,
+			},
+			err: `shape.go:12:1: Shape implements areaImpl which it declared abstract`,
+		},
+	})
+}
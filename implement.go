@@ -0,0 +1,639 @@
+package main
+
+import (
+	"fmt"
+	"github.com/tadnir/goop/go_generator"
+	"github.com/tadnir/goop/package_parser"
+	"github.com/tadnir/goop/utils"
+	"slices"
+	"strings"
+)
+
+func ImplementClass(file *go_generator.GoFileBuilder, class *Class) error {
+	if class.super != nil {
+		if class.super.IsExternal() && class.super.pkg.aliased {
+			file.AddAliasedImport(class.super.pkg.name, class.super.pkg.path)
+		} else if class.super.IsExternal() {
+			file.AddImport(class.super.pkg.path)
+		}
+
+		file.AddFunction(go_generator.NewGoFunctionBuilder("super").
+			SetReceiver("this", class.TypeName(), true).
+			AddReturnType("super", "*"+class.SuperTypeName()).
+			AddImplLines("return &this." + class.super.name),
+		)
+	}
+
+	rootVTable := class.RootVTable()
+	for _, vtable := range class.vtables {
+		vtableStruct := go_generator.NewGoStructBuilder(vtable.name)
+		for _, param := range vtable.typeParams {
+			vtableStruct.AddTypeParam(param.Name, param.Constraint)
+		}
+		if vtable == rootVTable {
+			file.AddImport("sync")
+			vtableStruct.AddVar(vtable.InitOnceName(), "sync.Once")
+			if !vtable.static {
+				vtableStruct.AddVar("goopClass", "*rtti.Class")
+				vtableStruct.AddVar("goopObject", "any")
+			}
+			if class.IsExtensible() {
+				// Set while the hooks initialize the object, which is the only time they may modify it
+				vtableStruct.AddVar("goopInitializing", "bool")
+			}
+			implementRTTI(file, class)
+		}
+
+		if vtable.static {
+			// The slots are shared by all the instances, so they're given the object they're called on
+			vtableStruct.AddVar(vtable.SlotsName(), "*"+vtable.SlotsName())
+			vtableStruct.AddVar("goopObject", "any")
+			implementStaticVTable(file, class, vtable)
+		} else {
+			for _, function := range vtable.functions {
+				vtableStruct.AddVar(function.name, function.signature)
+
+				// Exported virtual methods are also declared as methods, so the class satisfies interfaces with them
+				if utils.IsExported(function.name) {
+					file.AddFunction(dispatchMethod(class, function, fmt.Sprintf("this.%v.%v", vtable.name, function.name), nil))
+				}
+			}
+		}
+		file.AddStruct(vtableStruct)
+	}
+
+	superDescriptor := "nil"
+	if class.super != nil {
+		superDescriptor = class.super.DescriptorName()
+	}
+	file.AddImport("github.com/tadnir/goop/rtti").
+		AddVarInitializer(class.DescriptorName(), fmt.Sprintf("rtti.NewClass(%q, %v)", class.name, superDescriptor))
+
+	// Downcasts are methods of the root vtable owner, which can't be extended from another package, nor have type
+	// parameters of their own to downcast to a generic class
+	if rootOwner := class.RootVTableOwner(); rootOwner != nil && !rootOwner.IsExternal() && !class.IsGeneric() {
+		file.AddFunction(go_generator.NewGoFunctionBuilder("As"+utils.Capitalize(class.name)).
+			SetReceiver("this", rootOwner.TypeName(), true).
+			AddUnnamedReturnType("*"+class.name).
+			AddImplLines(
+				fmt.Sprintf("if object, ok := this.goopMostDerived().(interface{ goopAs%v() *%v }); ok {", utils.Capitalize(class.name), class.name),
+				fmt.Sprintf("\treturn object.goopAs%v()", utils.Capitalize(class.name)),
+				"}",
+				"return nil",
+			),
+		)
+		file.AddFunction(go_generator.NewGoFunctionBuilder("goopAs"+utils.Capitalize(class.name)).
+			SetReceiver("this", class.TypeName(), true).
+			AddUnnamedReturnType("*" + class.name).
+			AddImplLines("return this"),
+		)
+	}
+
+	for _, interfaceName := range class.interfaces {
+		implementInterface(file, class, interfaceName)
+	}
+
+	// A generic class can't be asserted to implement interfaces without instantiating it
+	for _, interfaceName := range append(slices.Clone(class.interfaces), class.implements...) {
+		if !class.IsGeneric() {
+			file.AddTypedVarInitializer("_", interfaceName, fmt.Sprintf("(*%v)(nil)", class.name))
+		}
+	}
+
+	// initClass may be called concurrently, the vtables are initialized by the first call only.
+	// The whole object shares the sync.Once of the root vtable, so it is initialized by the most derived class.
+	initFunc := go_generator.NewGoFunctionBuilder("initClass").
+		SetReceiver("this", class.TypeName(), true)
+	if rootVTable != nil && class.RootVTableOwner().IsExternal() {
+		initFunc.AddImplLines("this.GoopInitClass(this.initVTables)")
+	} else if rootVTable != nil {
+		initFunc.AddImplLines(fmt.Sprintf("this.%v.Do(this.initVTables)", rootVTable.InitOnceName()))
+	}
+	file.AddFunction(initFunc)
+
+	initVTablesFunc := go_generator.NewGoFunctionBuilder("initVTables").
+		SetReceiver("this", class.TypeName(), true)
+	if class.super != nil && class.super.IsExternal() {
+		initVTablesFunc.AddImplLines(fmt.Sprintf("this.%v.GoopInitVTables()", class.super.name))
+	} else if class.super != nil {
+		initVTablesFunc.AddImplLines(fmt.Sprintf("this.%v.initVTables()", class.super.name))
+	}
+
+	if rootVTable != nil && class.RootVTableOwner().IsExternal() {
+		initVTablesFunc.AddImplLines(
+			"// The most derived class initializes the runtime type information last",
+			fmt.Sprintf("this.GoopSetClass(%v, this)", class.DescriptorName()),
+		)
+	} else if rootVTable != nil && !rootVTable.static {
+		initVTablesFunc.AddImplLines(
+			"// The most derived class initializes the runtime type information last",
+			fmt.Sprintf("this.goopClass = %v", class.DescriptorName()),
+			fmt.Sprintf("this.%v.goopObject = this", rootVTable.name),
+		)
+	}
+
+	for _, vtable := range class.vtables {
+		if vtable.static {
+			continue
+		}
+
+		initVTablesFunc.AddImplLines(fmt.Sprintf("// Initializing VTable '%v'", vtable.name))
+		for _, function := range vtable.functions {
+			if function.abstract {
+				initVTablesFunc.AddImplLines(
+					fmt.Sprintf("this.%v.%v = %v {", vtable.name, function.name, function.signature),
+					fmt.Sprintf("panic(\"goop: call to abstract method %v.%v\")", class.name, function.name),
+					"}",
+				)
+				continue
+			}
+
+			initVTablesFunc.AddImplLines(fmt.Sprintf("this.%v.%v = this.%vImpl", vtable.name, function.name, function.name))
+		}
+	}
+
+	for _, override := range class.overrides {
+		if override.overriddenVtable.static {
+			continue
+		}
+
+		initVTablesFunc.AddImplLines(fmt.Sprintf("// Initializing Overrides for VTable '%v'", override.overriddenVtable.name))
+		external := class.VTableOwner(override.overriddenVtable).IsExternal()
+		for _, function := range override.functions {
+			if external {
+				initVTablesFunc.AddImplLines(fmt.Sprintf("this.%v(this.%vImpl)", SetHookName(function.name), function.name))
+				continue
+			}
+
+			initVTablesFunc.AddImplLines(fmt.Sprintf("this.%v.%v = this.%vImpl", override.overriddenVtable.name, function.name, function.name))
+		}
+	}
+
+	for _, owner := range class.Ancestry() {
+		for _, vtable := range owner.vtables {
+			if !vtable.static {
+				continue
+			}
+
+			implementStaticSlots(file, class, owner, vtable)
+			initVTablesFunc.AddImplLines(
+				fmt.Sprintf("// Pointing to the static VTable '%v' of %v", vtable.name, class.name),
+				fmt.Sprintf("this.%v = &%v", vtable.SlotsName(), vtable.SlotsValueName(class)),
+				fmt.Sprintf("this.%v.goopObject = this", vtable.name),
+			)
+		}
+	}
+
+	file.AddFunction(initVTablesFunc)
+
+	for _, override := range class.overrides {
+		for _, function := range override.functions {
+			if !class.HasSuperImplementation(override.overriddenVtable, function.name) {
+				continue
+			}
+
+			file.AddFunction(superCall(class, override.overriddenVtable, function))
+		}
+	}
+
+	implementConstructors(file, class)
+
+	if class.IsExtensible() {
+		implementHooks(file, class)
+	}
+
+	return nil
+}
+
+// implementHooks generates the exported methods letting classes of other packages inherit the class, as their
+// generated code can't access its unexported vtables and methods. The hooks modifying the object panic unless they're
+// called while it's initialized.
+func implementHooks(file *go_generator.GoFileBuilder, class *Class) {
+	file.AddFunction(go_generator.NewGoFunctionBuilder("GoopInitVTables").
+		SetReceiver("this", class.TypeName(), true).
+		AddImplLines(initializingGuard("GoopInitVTables")...).
+		AddImplLines("this.initVTables()"),
+	)
+
+	if class.constructor != nil {
+		constructFunc := go_generator.NewGoFunctionBuilder("GoopConstruct").
+			SetReceiver("this", class.TypeName(), true)
+		arguments := addForwardedParams(constructFunc, class.constructor)
+		file.AddFunction(constructFunc.AddImplLines(fmt.Sprintf("this.constructClass(%v)", strings.Join(arguments, ", "))))
+	}
+
+	implemented := []VFunc{}
+	for _, vtable := range class.vtables {
+		for _, function := range vtable.functions {
+			if !function.abstract {
+				implemented = append(implemented, function)
+			}
+		}
+
+		if vtable.static {
+			continue
+		}
+
+		if vtable == class.RootVTable() {
+			file.AddFunction(go_generator.NewGoFunctionBuilder("GoopInitClass").
+				SetReceiver("this", class.TypeName(), true).
+				AddParam("initVTables", "func()").
+				AddImplLines(
+					fmt.Sprintf("this.%v.Do(func() {", vtable.InitOnceName()),
+					fmt.Sprintf("\tthis.%v.goopInitializing = true", vtable.name),
+					fmt.Sprintf("\tdefer func() { this.%v.goopInitializing = false }()", vtable.name),
+					"\tinitVTables()",
+					"})",
+				),
+			)
+			file.AddFunction(go_generator.NewGoFunctionBuilder("GoopInitializing").
+				SetReceiver("this", class.TypeName(), true).
+				AddUnnamedReturnType("bool").
+				AddImplLines(fmt.Sprintf("return this.%v.goopInitializing", vtable.name)),
+			)
+			file.AddFunction(go_generator.NewGoFunctionBuilder("GoopSetClass").
+				SetReceiver("this", class.TypeName(), true).
+				AddParam("class", "*rtti.Class").
+				AddParam("object", "any").
+				AddImplLines(initializingGuard("GoopSetClass")...).
+				AddImplLines(
+					"this.goopClass = class",
+					fmt.Sprintf("this.%v.goopObject = object", vtable.name),
+				),
+			)
+		}
+
+		for _, function := range vtable.functions {
+			file.AddFunction(go_generator.NewGoFunctionBuilder(SetHookName(function.name)).
+				SetReceiver("this", class.TypeName(), true).
+				AddParam("impl", function.signature).
+				AddImplLines(initializingGuard(SetHookName(function.name))...).
+				AddImplLines(fmt.Sprintf("this.%v.%v = impl", vtable.name, function.name)),
+			)
+		}
+	}
+	for _, override := range class.overrides {
+		implemented = append(implemented, override.functions...)
+	}
+
+	for _, function := range implemented {
+		// Exported implementations are accessible as is
+		if utils.IsExported(function.name) {
+			continue
+		}
+
+		implFunc := go_generator.NewGoFunctionBuilder(ImplHookName(function.name)).
+			SetReceiver("this", class.TypeName(), true)
+		arguments := addForwardedParams(implFunc, function.method)
+		for _, ret := range package_parser.UngroupFields(function.method.ReturnTypes) {
+			implFunc.AddUnnamedReturnType(ret.VarType)
+		}
+
+		call := fmt.Sprintf("this.%vImpl(%v)", function.name, strings.Join(arguments, ", "))
+		if len(function.method.ReturnTypes) > 0 {
+			call = "return " + call
+		}
+		file.AddFunction(implFunc.AddImplLines(call))
+	}
+}
+
+// initializingGuard is the check of a hook panicking unless it's called while the object is initialized.
+func initializingGuard(hookName string) []string {
+	return []string{
+		"if !this.GoopInitializing() {",
+		fmt.Sprintf("\tpanic(\"goop: %v called outside of the initialization of the object\")", hookName),
+		"}",
+	}
+}
+
+// implementStaticVTable generates the struct of the function pointers shared by all the instances of a class, and the
+// methods of the class dispatching the virtual calls through it.
+func implementStaticVTable(file *go_generator.GoFileBuilder, class *Class, vtable *VTable) {
+	slotsStruct := go_generator.NewGoStructBuilder(vtable.SlotsName())
+	if vtable == class.RootVTable() {
+		slotsStruct.AddVar("goopClass", "*rtti.Class")
+	}
+	for _, function := range vtable.functions {
+		slotsStruct.AddVar(function.name, staticSlotSignature(class, function))
+		file.AddFunction(dispatchMethod(class, function, fmt.Sprintf("this.%v.%v", vtable.SlotsName(), function.name), []string{"this"}))
+	}
+	file.AddStruct(slotsStruct)
+}
+
+// implementStaticSlots generates the package level static vtable of the class for a vtable introduced by owner.
+// The slots receive the owner explicitly, and get the object of the class from it to call its implementation.
+func implementStaticSlots(file *go_generator.GoFileBuilder, class *Class, owner *Class, vtable *VTable) {
+	file.AddVarDeclaration(vtable.SlotsValueName(class), vtable.SlotsName())
+
+	initFunc := go_generator.NewGoFunctionBuilder("init").
+		AddImplLines(fmt.Sprintf("%v = %v{", vtable.SlotsValueName(class), vtable.SlotsName()))
+	if vtable == class.RootVTable() {
+		initFunc.AddImplLines(fmt.Sprintf("goopClass: %v,", class.DescriptorName()))
+	}
+	for _, function := range vtable.functions {
+		if !class.HasImplementation(vtable, function.name) {
+			initFunc.AddImplLines(
+				fmt.Sprintf("%v: %v {", function.name, staticSlotSignature(owner, function)),
+				fmt.Sprintf("panic(\"goop: call to abstract method %v.%v\")", owner.name, function.name),
+				"},",
+			)
+			continue
+		}
+
+		if class == owner {
+			initFunc.AddImplLines(fmt.Sprintf("%v: (*%v).%vImpl,", function.name, class.name, function.name))
+			continue
+		}
+
+		params, arguments := forwardedParams(function.method)
+		call := fmt.Sprintf("this.%v.goopObject.(*%v).%vImpl(%v)", vtable.name, class.name, function.name, strings.Join(arguments, ", "))
+		if len(function.method.ReturnTypes) > 0 {
+			call = "return " + call
+		}
+		initFunc.AddImplLines(
+			fmt.Sprintf("%v: func(%v)%v {", function.name, strings.Join(append([]string{"this *" + owner.name}, params...), ", "), resultsString(function.method)),
+			call,
+			"},",
+		)
+	}
+	file.AddFunction(initFunc.AddImplLines("}"))
+}
+
+// dispatchMethod generates the method of the class calling the virtual method function through the slot at slotPath.
+func dispatchMethod(class *Class, function VFunc, slotPath string, extraArguments []string) *go_generator.GoFunctionBuilder {
+	dispatchFunc := go_generator.NewGoFunctionBuilder(function.name).
+		SetReceiver("this", class.TypeName(), true)
+	arguments := addForwardedParams(dispatchFunc, function.method)
+	for _, ret := range package_parser.UngroupFields(function.method.ReturnTypes) {
+		dispatchFunc.AddUnnamedReturnType(ret.VarType)
+	}
+
+	call := fmt.Sprintf("%v(%v)", slotPath, strings.Join(append(extraArguments, arguments...), ", "))
+	if len(function.method.ReturnTypes) > 0 {
+		call = "return " + call
+	}
+
+	return dispatchFunc.AddImplLines(call)
+}
+
+// implementInterface generates an interface named interfaceName with all the exported virtual methods of the class.
+func implementInterface(file *go_generator.GoFileBuilder, class *Class, interfaceName string) {
+	// The interface of a generic class has the type parameters of the class, e.g. Repository[T]
+	interfaceName, _ = SplitTypeArgs(interfaceName)
+	interfaceBuilder := go_generator.NewGoInterfaceBuilder(interfaceName).
+		SetDoc(fmt.Sprintf("%v is implemented by %v and its subclasses.", interfaceName, class.name))
+	for _, param := range class.typeParams {
+		interfaceBuilder.AddTypeParam(param.Name, param.Constraint)
+	}
+	for _, ancestor := range class.Ancestry() {
+		for _, vtable := range ancestor.vtables {
+			for _, function := range vtable.functions {
+				if !utils.IsExported(function.name) {
+					continue
+				}
+
+				method := class.InstantiateFunction(ancestor, function.method)
+				params, _ := forwardedParams(method)
+				interfaceBuilder.AddMethod(function.name, fmt.Sprintf("(%v)%v", strings.Join(params, ", "), resultsString(method)))
+			}
+		}
+	}
+
+	file.AddInterface(interfaceBuilder)
+}
+
+// implementRTTI generates the methods of the class introducing the root vtable, describing the runtime type of
+// the object.
+func implementRTTI(file *go_generator.GoFileBuilder, class *Class) {
+	rootVTable := class.RootVTable()
+	classPath := "this.goopClass"
+	if rootVTable.static {
+		classPath = fmt.Sprintf("this.%v.goopClass", rootVTable.SlotsName())
+	}
+	objectPath := fmt.Sprintf("this.%v.goopObject", rootVTable.name)
+
+	file.AddFunction(go_generator.NewGoFunctionBuilder("Class").
+		SetReceiver("this", class.TypeName(), true).
+		AddUnnamedReturnType("*rtti.Class").
+		AddImplLines("return " + classPath),
+	)
+	file.AddFunction(go_generator.NewGoFunctionBuilder("ClassName").
+		SetReceiver("this", class.TypeName(), true).
+		AddUnnamedReturnType("string").
+		AddImplLines("return this.Class().Name()"),
+	)
+	file.AddFunction(go_generator.NewGoFunctionBuilder("IsA").
+		SetReceiver("this", class.TypeName(), true).
+		AddParam("class", "*rtti.Class").
+		AddUnnamedReturnType("bool").
+		AddImplLines("return this.Class().IsSubclassOf(class)"),
+	)
+	file.AddFunction(go_generator.NewGoFunctionBuilder("goopMostDerived").
+		SetReceiver("this", class.TypeName(), true).
+		AddUnnamedReturnType("any").
+		AddImplLines("return " + objectPath),
+	)
+}
+
+// staticSlotSignature is the signature of function in a static vtable, receiving the owner of the vtable explicitly.
+func staticSlotSignature(owner *Class, function VFunc) string {
+	params, _ := forwardedParams(function.method)
+	return fmt.Sprintf("func(%v)%v", strings.Join(append([]string{"this *" + owner.name}, params...), ", "), resultsString(function.method))
+}
+
+// implementConstructors generates the New function of the class unless it's abstract, and the methods chaining its
+// construct method to the construct method of its super.
+//
+// constructClass runs the construct method of the class after the one of its super when the latter takes no
+// arguments, otherwise construct is expected to call superConstruct with the arguments for its super.
+func implementConstructors(file *go_generator.GoFileBuilder, class *Class) {
+	var superConstructor *package_parser.Function
+	superConstructName := "constructClass"
+	if class.super != nil && class.super.Constructor() != nil {
+		superConstructor = class.InstantiateFunction(class.super.ConstructorClass(), class.super.Constructor())
+		if class.super.ConstructorClass().IsExternal() {
+			superConstructName = "GoopConstruct"
+		}
+	}
+
+	if class.constructor != nil {
+		constructFunc := go_generator.NewGoFunctionBuilder("constructClass").
+			SetReceiver("this", class.TypeName(), true)
+		if superConstructor != nil && len(superConstructor.ArgumentTypes) == 0 {
+			constructFunc.AddImplLines(fmt.Sprintf("this.%v.%v()", class.super.name, superConstructName))
+		}
+		arguments := addForwardedParams(constructFunc, class.constructor)
+		file.AddFunction(constructFunc.AddImplLines(fmt.Sprintf("this.construct(%v)", strings.Join(arguments, ", "))))
+
+		if superConstructor != nil {
+			superFunc := go_generator.NewGoFunctionBuilder("superConstruct").
+				SetReceiver("this", class.TypeName(), true)
+			arguments := addForwardedParams(superFunc, superConstructor)
+			file.AddFunction(superFunc.AddImplLines(
+				fmt.Sprintf("this.%v.%v(%v)", class.super.name, superConstructName, strings.Join(arguments, ", ")),
+			))
+		}
+	}
+
+	// Abstract classes can't be instantiated, as calling their abstract methods would panic
+	if class.IsAbstract() {
+		return
+	}
+
+	newFunc := go_generator.NewGoFunctionBuilder(class.NewName())
+	for _, param := range class.typeParams {
+		newFunc.AddTypeParam(param.Name, param.Constraint)
+	}
+	newFunc.AddUnnamedReturnType("*"+class.TypeName()).
+		AddImplLines(
+			fmt.Sprintf("this := new(%v)", class.TypeName()),
+			"this.initClass()",
+		)
+	if constructorClass := class.ConstructorClass(); constructorClass != nil {
+		constructName := "constructClass"
+		if constructorClass.IsExternal() {
+			constructName = "GoopConstruct"
+		}
+		arguments := addForwardedParams(newFunc, class.InstantiateFunction(constructorClass, constructorClass.constructor))
+		newFunc.AddImplLines(fmt.Sprintf("this.%v(%v)", constructName, strings.Join(arguments, ", ")))
+	}
+	file.AddFunction(newFunc.AddImplLines("return this"))
+}
+
+// superCall generates a method calling the implementation of function that the class overrides.
+// The call goes through the embedded super struct, so it reaches the nearest ancestor implementing it.
+func superCall(class *Class, vtable *VTable, function VFunc) *go_generator.GoFunctionBuilder {
+	superFunc := go_generator.NewGoFunctionBuilder(SuperCallName(function.name)).
+		SetReceiver("this", class.TypeName(), true)
+
+	arguments := addForwardedParams(superFunc, function.method)
+	for _, ret := range package_parser.UngroupFields(function.method.ReturnTypes) {
+		superFunc.AddUnnamedReturnType(ret.VarType)
+	}
+
+	implName := function.name + "Impl"
+	if class.SuperImplementation(vtable, function.name).IsExternal() && !utils.IsExported(function.name) {
+		implName = ImplHookName(function.name)
+	}
+
+	call := fmt.Sprintf("this.%v.%v(%v)", class.super.name, implName, strings.Join(arguments, ", "))
+	if len(function.method.ReturnTypes) > 0 {
+		call = "return " + call
+	}
+
+	return superFunc.AddImplLines(call)
+}
+
+// addForwardedParams adds the parameters of method to function, and returns the arguments forwarding them.
+func addForwardedParams(function *go_generator.GoFunctionBuilder, method *package_parser.Function) []string {
+	arguments := []string{}
+	for i, argument := range package_parser.UngroupFields(method.ArgumentTypes) {
+		name := forwardedParamName(i, argument)
+		function.AddParam(name, argument.VarType)
+		arguments = append(arguments, forwardedArgument(name, argument))
+	}
+
+	return arguments
+}
+
+// forwardedParams returns the parameters declaration of method, and the arguments forwarding them.
+func forwardedParams(method *package_parser.Function) ([]string, []string) {
+	params := []string{}
+	arguments := []string{}
+	for i, argument := range package_parser.UngroupFields(method.ArgumentTypes) {
+		name := forwardedParamName(i, argument)
+		params = append(params, fmt.Sprintf("%v %v", name, argument.VarType))
+		arguments = append(arguments, forwardedArgument(name, argument))
+	}
+
+	return params, arguments
+}
+
+// forwardedArgument is the argument forwarding the parameter name, spreading variadic parameters.
+func forwardedArgument(name string, argument *package_parser.FieldDeclaration) string {
+	if _, isVariadic := argument.Type.(*package_parser.EllipsisType); isVariadic {
+		return name + "..."
+	}
+
+	return name
+}
+
+func forwardedParamName(i int, argument *package_parser.FieldDeclaration) string {
+	if len(argument.Names) == 1 && argument.Names[0] != "_" {
+		return argument.Names[0]
+	}
+
+	return fmt.Sprintf("arg%d", i)
+}
+
+// resultsString is the results part of the signature of method.
+func resultsString(method *package_parser.Function) string {
+	if len(method.ReturnTypes) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%v)", strings.Join(utils.Map(slices.Values(method.ReturnTypes), (*package_parser.FieldDeclaration).String), ", "))
+}
+
+// ImplementClassTests generates a test initializing the class concurrently, to be run under the race detector.
+func ImplementClassTests(file *go_generator.GoFileBuilder, class *Class) {
+	// Generic classes are tested instantiated with any, when their constraints allow it
+	typeArgs := []string{}
+	for _, param := range class.typeParams {
+		if !slices.Contains([]string{"any", "comparable", "interface{}"}, param.Constraint) {
+			return
+		}
+		typeArgs = append(typeArgs, "any")
+	}
+
+	instance := class.name
+	if len(typeArgs) > 0 {
+		instance = fmt.Sprintf("%v[%v]", class.name, strings.Join(typeArgs, ", "))
+	}
+
+	file.AddImport("sync").AddImport("testing")
+
+	testFunc := go_generator.NewGoFunctionBuilder(fmt.Sprintf("Test%vInitClassRace", utils.Capitalize(class.name))).
+		AddParam("t", "*testing.T").
+		AddImplLines(
+			fmt.Sprintf("this := new(%v)", instance),
+			"var wg sync.WaitGroup",
+			"for i := 0; i < 8; i++ {",
+			"\twg.Add(1)",
+			"\tgo func() {",
+			"\t\tdefer wg.Done()",
+			"\t\tthis.initClass()",
+		)
+	for _, ancestor := range class.Ancestry() {
+		// The vtables of classes of other packages aren't accessible
+		if ancestor.IsExternal() {
+			continue
+		}
+
+		for _, vtable := range ancestor.vtables {
+			if vtable.static {
+				testFunc.AddImplLines(
+					fmt.Sprintf("\t\tif this.%v == nil {", vtable.SlotsName()),
+					fmt.Sprintf("\t\t\tt.Error(\"%v.%v wasn't initialized\")", class.name, vtable.SlotsName()),
+					"\t\t}",
+				)
+				continue
+			}
+
+			for _, function := range vtable.functions {
+				testFunc.AddImplLines(
+					fmt.Sprintf("\t\tif this.%v.%v == nil {", vtable.name, function.name),
+					fmt.Sprintf("\t\t\tt.Error(\"%v.%v wasn't initialized\")", class.name, function.name),
+					"\t\t}",
+				)
+			}
+		}
+	}
+	testFunc.AddImplLines(
+		"\t}()",
+		"}",
+		"wg.Wait()",
+	)
+
+	file.AddFunction(testFunc)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const constructedBaseSource = `package main
+
+type Shape struct {
+	shapeVtable ` + "`goop:\"vtable\"`" + `
+	_           [0]shapeAbstract ` + "`goop:\"abstract\"`" + `
+	name        string
+}
+
+type shapeAbstract interface {
+	area() int
+}
+
+func (s *Shape) construct(name string) {
+	s.name = name
+}
+`
+
+func TestSuperConstruct(t *testing.T) {
+	runClassTests(t, []classTest{
+		{
+			name: "construct not calling superConstruct",
+			files: map[string]string{
+				"shape.go": constructedBaseSource,
+				"rect.go": `package main
+
+type Rect struct {
+	Shape ` + "`goop:\"super\"`" + `
+	side  int
+}
+
+func (r *Rect) construct(side int) {
+	r.side = side
+}
+
+func (r *Rect) areaImpl() int { return r.side * r.side }
+
+func main() {}
+`,
+			},
+			err: "rect.go:8:1: construct of Rect must call superConstruct, as the construct method of Shape takes arguments",
+		},
+		{
+			name: "construct calling superConstruct",
+			files: map[string]string{
+				"shape.go": constructedBaseSource,
+				"rect.go": `package main
+
+type Rect struct {
+	Shape ` + "`goop:\"super\"`" + `
+	side  int
+}
+
+func (r *Rect) construct(side int) {
+	r.superConstruct("rect")
+	r.side = side
+}
+
+func (r *Rect) areaImpl() int { return r.side * r.side }
+
+func main() {}
+`,
+			},
+		},
+		{
+			name: "super construct without arguments",
+			files: map[string]string{
+				"shape.go": `package main
+
+type Shape struct {
+	shapeVtable ` + "`goop:\"vtable\"`" + `
+	name        string
+}
+
+func (s *Shape) construct() {
+	s.name = "shape"
+}
+
+type Rect struct {
+	Shape ` + "`goop:\"super\"`" + `
+	side  int
+}
+
+func (r *Rect) construct(side int) {
+	r.side = side
+}
+
+func main() {}
+`,
+			},
+		},
+	})
+}
+
+func TestConstructors(t *testing.T) {
+	dir := goopModule(t, map[string]string{
+		"shape.go": constructedBaseSource,
+		"rect.go": `package main
+
+import "fmt"
+
+type Rect struct {
+	Shape ` + "`goop:\"super\"`" + `
+	side  int
+}
+
+func (r *Rect) construct(side int) {
+	r.superConstruct("rect")
+	r.side = side
+}
+
+func (r *Rect) areaImpl() int { return r.side * r.side }
+
+func main() {
+	rect := NewRect(3)
+	fmt.Println(rect.name, rect.area())
+}
+`,
+	})
+
+	generated := generateModule(t, dir, false)
+	if shape := generated[filepath.Join(dir, "shape_goop.go")]; strings.Contains(shape, "func NewShape") || strings.Contains(shape, "func newShape") {
+		t.Errorf("the abstract class Shape has a constructor:\n%s", shape)
+	}
+	if rect := generated[filepath.Join(dir, "rect_goop.go")]; !strings.Contains(rect, "func NewRect(side int) *Rect") {
+		t.Errorf("the class Rect has no constructor:\n%s", rect)
+	}
+
+	if out := goCommand(t, dir, "run", "."); out != "rect 9\n" {
+		t.Errorf("expected the constructed rect of area 9, got %q", out)
+	}
+}
+
+func TestStaticDispatch(t *testing.T) {
+	dir := goopModule(t, map[string]string{
+		"animal.go": `package main
+
+import "fmt"
+
+type Animal struct {
+	animalVtable ` + "`goop:\"vtable,static\"`" + `
+}
+
+func (a *Animal) nameImpl() string { return "animal" }
+
+func (a *Animal) greetImpl() string { return "I am " + a.name() }
+
+// Dog overrides nothing, dispatching to Animal
+type Dog struct {
+	Animal ` + "`goop:\"super\"`" + `
+}
+
+type Puppy struct {
+	Dog ` + "`goop:\"super\"`" + `
+}
+
+func (p *Puppy) nameImpl() string { return "puppy of " + p.superName() }
+
+func main() {
+	puppy := NewPuppy()
+	dog := NewDog()
+	for _, animal := range []*Animal{NewAnimal(), &dog.Animal, &puppy.Animal, &puppy.Dog.Animal} {
+		fmt.Println(animal.greet())
+	}
+	fmt.Println(dog.name(), puppy.Dog.name())
+}
+`,
+	})
+
+	generated := generateModule(t, dir, false)
+	source := generated[filepath.Join(dir, "animal_goop.go")]
+	// The slots of every class are set up by init, dispatching to the object stored in the instance
+	for _, expected := range []string{
+		"type animalVtableSlots struct",
+		"func init()",
+		"this.animalVtable.goopObject.(*Dog).nameImpl()",
+		"this.animalVtable.goopObject.(*Puppy).nameImpl()",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("generated file doesn't contain %q:\n%s", expected, source)
+		}
+	}
+
+	expected := "I am animal\nI am animal\nI am puppy of animal\nI am puppy of animal\nanimal puppy of animal\n"
+	if out := goCommand(t, dir, "run", "."); out != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
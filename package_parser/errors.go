@@ -0,0 +1,110 @@
+package package_parser
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// ParseError is a problem in the parsed source, located at the offending construct.
+type ParseError struct {
+	Position token.Position
+	// Construct is the source of the offending construct, empty if it's unknown
+	Construct string
+	Message   string
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.Position.IsValid() {
+		sb.WriteString(e.Position.String())
+		sb.WriteString(": ")
+	}
+
+	sb.WriteString(e.Message)
+	if e.Construct != "" {
+		// Only the first line locates multiline constructs, e.g. a struct
+		construct, _, isMultiline := strings.Cut(e.Construct, "\n")
+		if isMultiline {
+			construct += " ..."
+		}
+		sb.WriteString(fmt.Sprintf(" in %q", construct))
+	}
+	return sb.String()
+}
+
+// newParseError creates an error located at node, quoting its source.
+func newParseError(source *Source, node interface {
+	Pos() token.Pos
+	End() token.Pos
+}, format string, args ...any) *ParseError {
+	return &ParseError{
+		Position:  source.Position(node.Pos()),
+		Construct: source.Text(node),
+		Message:   fmt.Sprintf(format, args...),
+	}
+}
+
+// Errorf creates an error located at position, e.g. the position of a parsed declaration.
+func Errorf(position token.Position, format string, args ...any) *ParseError {
+	return &ParseError{Position: position, Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorList collects the errors of a parsing run, so that all of them are reported at once.
+type ErrorList []*ParseError
+
+// Add adds err to the list, flattening lists and syntax errors, errors without a position are kept as messages.
+func (list *ErrorList) Add(err error) {
+	var parseError *ParseError
+	var parseErrors ErrorList
+	var syntaxErrors scanner.ErrorList
+	switch {
+	case errors.As(err, &parseErrors):
+		*list = append(*list, parseErrors...)
+	case errors.As(err, &parseError):
+		*list = append(*list, parseError)
+	case errors.As(err, &syntaxErrors):
+		for _, syntaxError := range syntaxErrors {
+			*list = append(*list, &ParseError{Position: syntaxError.Pos, Message: syntaxError.Msg})
+		}
+	case err != nil:
+		*list = append(*list, &ParseError{Message: err.Error()})
+	}
+}
+
+// Err returns the list sorted by position, or nil if it's empty.
+func (list ErrorList) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(list)
+	slices.SortStableFunc(sorted, func(e1 *ParseError, e2 *ParseError) int {
+		if file := strings.Compare(e1.Position.Filename, e2.Position.Filename); file != 0 {
+			return file
+		}
+		if e1.Position.Line != e2.Position.Line {
+			return e1.Position.Line - e2.Position.Line
+		}
+		return e1.Position.Column - e2.Position.Column
+	})
+	return sorted
+}
+
+func (list ErrorList) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	default:
+		messages := []string{fmt.Sprintf("%d errors:", len(list))}
+		for _, err := range list {
+			messages = append(messages, err.Error())
+		}
+		return strings.Join(messages, "\n")
+	}
+}
@@ -0,0 +1,130 @@
+package package_parser
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrorListAdd(t *testing.T) {
+	_, syntaxErr := parser.ParseFile(token.NewFileSet(), "bad.go", "package p\n\nvar x int = )\n", parser.AllErrors)
+	if syntaxErr == nil {
+		t.Fatal("expected a syntax error")
+	}
+
+	position := token.Position{Filename: "a.go", Line: 3, Column: 2}
+	tests := []struct {
+		name string
+		err  error
+		// expected are the errors added to the list
+		expected []string
+	}{
+		{name: "nil", err: nil, expected: []string{}},
+		{name: "parse error", err: Errorf(position, "bad %s", "field"), expected: []string{"a.go:3:2: bad field"}},
+		{name: "wrapped parse error", err: fmt.Errorf("wrapped: %w", Errorf(position, "bad")), expected: []string{"a.go:3:2: bad"}},
+		{
+			name:     "error list",
+			err:      ErrorList{Errorf(position, "first"), Errorf(position, "second")},
+			expected: []string{"a.go:3:2: first", "a.go:3:2: second"},
+		},
+		{
+			name:     "syntax errors",
+			err:      syntaxErr,
+			expected: []string{"bad.go:3:13: expected operand, found ')'", "bad.go:3:15: expected ';', found 'EOF'"},
+		},
+		{name: "unpositioned", err: errors.New("plain"), expected: []string{"plain"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var errs ErrorList
+			errs.Add(test.err)
+			if len(errs) != len(test.expected) {
+				t.Fatalf("expected %d errors, got %v", len(test.expected), errs)
+			}
+			for i, expected := range test.expected {
+				if errs[i].Error() != expected {
+					t.Errorf("expected %q, got %q", expected, errs[i].Error())
+				}
+			}
+		})
+	}
+}
+
+func TestErrorListErr(t *testing.T) {
+	var errs ErrorList
+	if errs.Err() != nil {
+		t.Fatalf("expected no error for an empty list, got %v", errs.Err())
+	}
+
+	errs.Add(Errorf(token.Position{Filename: "b.go", Line: 1, Column: 1}, "third"))
+	errs.Add(Errorf(token.Position{Filename: "a.go", Line: 7, Column: 1}, "second"))
+	errs.Add(Errorf(token.Position{Filename: "a.go", Line: 2, Column: 5}, "first"))
+	errs.Add(errors.New("unpositioned"))
+	expected := "4 errors:\nunpositioned\na.go:2:5: first\na.go:7:1: second\nb.go:1:1: third"
+	if err := errs.Err(); err == nil || err.Error() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%v", expected, err)
+	}
+
+	// Sorting doesn't reorder the list itself
+	if errs[0].Message != "third" {
+		t.Errorf("Err reordered the list to %v", errs)
+	}
+
+	single := ErrorList{Errorf(token.Position{Filename: "a.go", Line: 1, Column: 1}, "only")}
+	if single.Err().Error() != "a.go:1:1: only" {
+		t.Errorf("expected a single error without a count, got %q", single.Err())
+	}
+}
+
+func TestParseErrorConstruct(t *testing.T) {
+	err := &ParseError{
+		Position:  token.Position{Filename: "a.go", Line: 3, Column: 1},
+		Construct: "type S struct {\n\ta int\n}",
+		Message:   "bad struct",
+	}
+
+	expected := `a.go:3:1: bad struct in "type S struct { ..."`
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestParseGoFileErrors(t *testing.T) {
+	const src = `package p
+
+type S struct{}
+
+func (a, b *S) twice() {}
+
+func (s [2]S) array() {}
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "s.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ParseGoFile(dir, "s.go")
+	var errs ErrorList
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected an error list, got %v", err)
+	}
+
+	path := filepath.Join(dir, "s.go")
+	expected := []string{
+		path + `:5:6: method twice must have exactly one receiver in "(a, b *S)"`,
+		path + `:7:6: unsupported receiver type of method array in "(s [2]S)"`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), errs)
+	}
+	for i := range expected {
+		if errs[i].Error() != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], errs[i].Error())
+		}
+	}
+}
@@ -2,47 +2,88 @@ package package_parser
 
 import (
 	"fmt"
+	"github.com/tadnir/goop/utils"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 type FieldDeclaration struct {
-	Name    *string
+	// Names are the names declared together with the type, e.g. a and b in "a, b int", empty for embedded fields and
+	// unnamed parameters
+	Names []string
+	Type  TypeExpr
+	// VarType is the formatted Type
 	VarType string
 	Tag     reflect.StructTag
+	// Position is where the field starts, the zero position when parsed without a Source
+	Position token.Position
+	// TagPosition is where the tag starts, the zero position when there's no tag
+	TagPosition token.Position
+	// ResolvedType is the type-checked Type, nil unless the package was type checked
+	ResolvedType types.Type
+	// node is the syntax of the field, used to find its type when type checking
+	node *ast.Field
 }
 
-func ParseFieldDeclaration(decl *ast.Field) *FieldDeclaration {
-	var name *string = nil
-	if len(decl.Names) > 1 {
-		panic(fmt.Sprintf("unexpected number of names: %+v", decl.Names))
-	} else if len(decl.Names) == 1 {
-		name = &decl.Names[0].Name
+// ParseFieldDeclaration parses a struct field or a parameter, located by source.
+func ParseFieldDeclaration(source *Source, decl *ast.Field) (*FieldDeclaration, error) {
+	names := []string{}
+	for _, name := range decl.Names {
+		names = append(names, name.Name)
 	}
 
 	var tag reflect.StructTag
+	var tagPosition token.Position
 	if decl.Tag != nil {
-		if decl.Tag.Kind != token.STRING {
-			panic(fmt.Sprintf("unexpected tag type: %+v", decl.Tag))
+		tagValue, err := strconv.Unquote(decl.Tag.Value)
+		if decl.Tag.Kind != token.STRING || err != nil {
+			return nil, newParseError(source, decl.Tag, "field tag isn't a string literal")
 		}
-		tag = reflect.StructTag(strings.Trim(decl.Tag.Value, "`"))
+		tag = reflect.StructTag(tagValue)
+		tagPosition = source.Position(decl.Tag.Pos())
 	}
 
-	switch expr := decl.Type.(type) {
-	case *ast.Ident:
-		return &FieldDeclaration{Name: name, VarType: expr.String(), Tag: tag}
-	case *ast.StarExpr:
-		return &FieldDeclaration{Name: name, VarType: "*" + expr.X.(*ast.Ident).String(), Tag: tag}
-	default:
-		panic(fmt.Sprintf("unknown field type %T", expr))
+	fieldType := ParseTypeExpr(decl.Type)
+	return &FieldDeclaration{
+		Names:       names,
+		Type:        fieldType,
+		VarType:     fieldType.String(),
+		Tag:         tag,
+		Position:    source.Position(decl.Pos()),
+		TagPosition: tagPosition,
+		node:        decl,
+	}, nil
+}
+
+// Ungroup splits a declaration of several names to a declaration per name.
+func (f *FieldDeclaration) Ungroup() []*FieldDeclaration {
+	if len(f.Names) <= 1 {
+		return []*FieldDeclaration{f}
+	}
+
+	fields := []*FieldDeclaration{}
+	for _, name := range f.Names {
+		field := *f
+		field.Names = []string{name}
+		fields = append(fields, &field)
 	}
+
+	return fields
+}
+
+// UngroupFields splits the declarations of several names in fields, e.g. the parameters "a, b int" to "a int, b int".
+func UngroupFields(fields []*FieldDeclaration) []*FieldDeclaration {
+	return slices.Concat(utils.Map(slices.Values(fields), (*FieldDeclaration).Ungroup)...)
 }
 
 func (f FieldDeclaration) String() string {
-	if f.Name != nil {
-		return fmt.Sprintf("%s %s", *f.Name, f.VarType)
+	if len(f.Names) > 0 {
+		return fmt.Sprintf("%s %s", strings.Join(f.Names, ", "), f.VarType)
 	}
 
 	return fmt.Sprintf("%s", f.VarType)
@@ -0,0 +1,117 @@
+package package_parser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+)
+
+func TestFieldDeclarationNames(t *testing.T) {
+	typeExpr, err := ParseTypeString("struct{ a, b int; Base; *pkg.Other; c string `goop:\"super\"` }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := typeExpr.(*StructType).Fields
+	tests := []struct {
+		names    []string
+		varType  string
+		expected string
+	}{
+		{names: []string{"a", "b"}, varType: "int", expected: "a, b int"},
+		{names: nil, varType: "Base", expected: "Base"},
+		{names: nil, varType: "*pkg.Other", expected: "*pkg.Other"},
+		{names: []string{"c"}, varType: "string", expected: "c string"},
+	}
+
+	if len(fields) != len(tests) {
+		t.Fatalf("expected %d fields, got %d", len(tests), len(fields))
+	}
+	for i, test := range tests {
+		if !slices.Equal(fields[i].Names, test.names) {
+			t.Errorf("field %d: expected the names %v, got %v", i, test.names, fields[i].Names)
+		}
+		if fields[i].VarType != test.varType {
+			t.Errorf("field %d: expected the type %q, got %q", i, test.varType, fields[i].VarType)
+		}
+		if fields[i].String() != test.expected {
+			t.Errorf("field %d: expected %q, got %q", i, test.expected, fields[i].String())
+		}
+	}
+}
+
+func TestUngroupFields(t *testing.T) {
+	tests := []struct {
+		source  string
+		params  []string
+		results []string
+	}{
+		{source: "func()", params: []string{}, results: []string{}},
+		{source: "func(a int)", params: []string{"a int"}, results: []string{}},
+		{source: "func(a, b int, c string)", params: []string{"a int", "b int", "c string"}, results: []string{}},
+		{source: "func(int, string) error", params: []string{"int", "string"}, results: []string{"error"}},
+		{source: "func(a, b int, c ...string) (n, m int, err error)", params: []string{"a int", "b int", "c ...string"}, results: []string{"n int", "m int", "err error"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			typeExpr, err := ParseTypeString(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			funcType := typeExpr.(*FuncType)
+			format := func(fields []*FieldDeclaration) []string {
+				formatted := []string{}
+				for _, field := range UngroupFields(fields) {
+					formatted = append(formatted, field.String())
+				}
+				return formatted
+			}
+			if params := format(funcType.Params); !slices.Equal(params, test.params) {
+				t.Errorf("expected the parameters %q, got %q", test.params, params)
+			}
+			if results := format(funcType.Results); !slices.Equal(results, test.results) {
+				t.Errorf("expected the results %q, got %q", test.results, results)
+			}
+
+			// Ungrouping copies the declarations, and keeps the formatted signature
+			if funcType.String() != test.source {
+				t.Errorf("expected the signature %q after ungrouping, got %q", test.source, funcType.String())
+			}
+		})
+	}
+}
+
+func TestUngroupKeepsPositions(t *testing.T) {
+	const src = "package p\n\ntype S struct {\n\ta, b int `goop:\"x\"`\n}\n"
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, "s.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structType := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	field, err := ParseFieldDeclaration(NewSource(fileSet, []byte(src)), structType.Fields.List[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := field.Ungroup()
+	if len(fields) != 2 || fields[0].Names[0] != "a" || fields[1].Names[0] != "b" {
+		t.Fatalf("expected the fields a and b, got %v", fields)
+	}
+	for _, ungrouped := range fields {
+		if ungrouped.Position.String() != "s.go:4:2" || ungrouped.TagPosition.String() != "s.go:4:11" {
+			t.Errorf("expected the positions of the grouped field, got %s and %s", ungrouped.Position, ungrouped.TagPosition)
+		}
+		if ungrouped.Tag.Get("goop") != "x" {
+			t.Errorf("expected the tag of the grouped field, got %q", ungrouped.Tag)
+		}
+	}
+	if !slices.Equal(field.Names, []string{"a", "b"}) {
+		t.Errorf("ungrouping modified the grouped field to %v", field.Names)
+	}
+}
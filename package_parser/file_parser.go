@@ -7,6 +7,7 @@ import (
 	"go/parser"
 	"go/token"
 	"maps"
+	"os"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -19,25 +20,38 @@ type GoFile struct {
 	functions   []*Function
 	structs     map[string]*StructDeclaration
 	interfaces  map[string]*InterfaceDeclaration
-	variables   map[string]*FieldDeclaration
+	namedTypes  map[string]*NamedTypeDeclaration
+	// values are the package level variables and constants, in their declaration order
+	values []*ValueDeclaration
+	// syntax is the syntax tree of the file, kept for type checking the package
+	syntax *ast.File
 }
 
 func ParseGoFile(packagePath string, fileName string) (*GoFile, error) {
-	file := &GoFile{fileName: fileName, structs: map[string]*StructDeclaration{}, interfaces: map[string]*InterfaceDeclaration{}, variables: map[string]*FieldDeclaration{}}
+	return parseGoFile(token.NewFileSet(), packagePath, fileName)
+}
+
+// parseGoFile parses a file into fileSet, which is shared by the files of a package for type checking it.
+// The errors of every declaration are returned together as an ErrorList, along with the declarations parsed.
+func parseGoFile(fileSet *token.FileSet, packagePath string, fileName string) (*GoFile, error) {
+	file := &GoFile{fileName: fileName, structs: map[string]*StructDeclaration{}, interfaces: map[string]*InterfaceDeclaration{}, namedTypes: map[string]*NamedTypeDeclaration{}}
 	filePath := filepath.Join(packagePath, fileName)
 
 	// Parse the file
-	fileSet := token.NewFileSet()
-	f, err := parser.ParseFile(fileSet, filePath, nil, parser.ParseComments)
+	src, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to parse '%s': %s", filePath, err)
+		return nil, fmt.Errorf("Unable to read '%s': %s", filePath, err)
 	}
 
-	// Get the package name
-	if f.Name == nil {
-		return nil, fmt.Errorf("Missing package Name in '%s'", filePath)
+	var errs ErrorList
+	f, err := parser.ParseFile(fileSet, filePath, src, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		errs.Add(err)
+		return nil, errs.Err()
 	}
+	source := NewSource(fileSet, src)
 	file.packageName = f.Name.Name
+	file.syntax = f
 	file.imports = utils.Map(slices.Values(f.Imports), ParseImport)
 
 	// Get declarations
@@ -46,31 +60,74 @@ func ParseGoFile(packagePath string, fileName string) (*GoFile, error) {
 		case *ast.GenDecl:
 			switch decl.Tok {
 			case token.TYPE:
-				stDecl, inDecl := ParseTypeDeclaration(decl)
-				if stDecl != nil {
+				structs, interfaces, namedTypes, err := ParseTypeDeclaration(source, decl)
+				errs.Add(err)
+				for _, stDecl := range structs {
 					file.structs[stDecl.Name] = stDecl
 				}
-				if inDecl != nil {
+				for _, inDecl := range interfaces {
 					file.interfaces[inDecl.Name] = inDecl
 				}
-			case token.VAR:
-				fmt.Printf("var %v\n", decl)
-			case token.CONST:
-				fmt.Printf("const %v\n", decl)
+				for _, namedDecl := range namedTypes {
+					file.namedTypes[namedDecl.Name] = namedDecl
+				}
+			case token.VAR, token.CONST:
+				values, err := ParseValueDeclaration(source, decl)
+				errs.Add(err)
+				file.values = append(file.values, values...)
+			case token.IMPORT:
+				// The imports are parsed from f.Imports
 			default:
-				fmt.Printf("unknown tok: %v\n", decl.Tok)
+				errs.Add(newParseError(source, decl, "unknown declaration %v", decl.Tok))
 			}
 		case *ast.FuncDecl:
-			function := ParseFunction(decl)
+			function, err := ParseFunction(source, decl)
+			if err != nil {
+				errs.Add(err)
+				continue
+			}
 			file.functions = append(file.functions, function)
 		}
 	}
 
-	return file, nil
+	return file, errs.Err()
+}
+
+func (file *GoFile) GetName() string {
+	return file.fileName
 }
 
 func (file *GoFile) GetStructs() []*StructDeclaration {
-	return slices.Collect(maps.Values(file.structs))
+	return slices.SortedFunc(maps.Values(file.structs), func(s1 *StructDeclaration, s2 *StructDeclaration) int {
+		return strings.Compare(s1.Name, s2.Name)
+	})
+}
+
+func (file *GoFile) GetInterfaces() []*InterfaceDeclaration {
+	return slices.SortedFunc(maps.Values(file.interfaces), func(i1 *InterfaceDeclaration, i2 *InterfaceDeclaration) int {
+		return strings.Compare(i1.Name, i2.Name)
+	})
+}
+
+// GetNamedTypes returns the types declared by the file which are neither structs nor interfaces, including aliases.
+func (file *GoFile) GetNamedTypes() []*NamedTypeDeclaration {
+	return slices.SortedFunc(maps.Values(file.namedTypes), func(t1 *NamedTypeDeclaration, t2 *NamedTypeDeclaration) int {
+		return strings.Compare(t1.Name, t2.Name)
+	})
+}
+
+// GetVariables returns the package level variables declared by the file, in their declaration order.
+func (file *GoFile) GetVariables() []*ValueDeclaration {
+	return slices.DeleteFunc(slices.Clone(file.values), func(value *ValueDeclaration) bool { return value.IsConst })
+}
+
+// GetConstants returns the constants declared by the file, in their declaration order.
+func (file *GoFile) GetConstants() []*ValueDeclaration {
+	return slices.DeleteFunc(slices.Clone(file.values), func(value *ValueDeclaration) bool { return !value.IsConst })
+}
+
+func (file *GoFile) GetImports() []*Import {
+	return file.imports
 }
 
 func (file *GoFile) GetFunctions() []*Function {
@@ -98,6 +155,15 @@ func (file *GoFile) String() string {
 		sb.WriteString("\n")
 	}
 
+	for _, value := range file.values {
+		sb.WriteString(value.String())
+	}
+
+	for _, namedType := range file.GetNamedTypes() {
+		sb.WriteString(namedType.String())
+		sb.WriteString("\n")
+	}
+
 	for _, fn := range file.functions {
 		sb.WriteString(fn.String())
 		sb.WriteString("\n")
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/tadnir/goop/utils"
 	"go/ast"
+	"go/token"
+	"go/types"
 	"slices"
 	"strings"
 )
@@ -11,66 +13,182 @@ import (
 type Function struct {
 	Name          string
 	Doc           *string
+	Directives    []string
+	TypeParams    []*TypeParam
 	ArgumentTypes []*FieldDeclaration
 	ReturnTypes   []*FieldDeclaration
 	Receiver      *FunctionReceiver
-	Body          string
+	// Body is the source of the function's body including its braces, empty for declarations without a body
+	Body string
+	// BodyNode is the syntax tree of the body, nil for declarations without a body and interface methods
+	BodyNode *ast.BlockStmt
+	// Position is where the function's declaration starts, the zero position when parsed without a Source
+	Position token.Position
+	// Object is the type-checked function, nil unless the package was type checked
+	Object *types.Func
+	// nameIdent is the identifier declaring the function, used to find its object when type checking
+	nameIdent *ast.Ident
 }
 
 type FunctionReceiver struct {
 	Name     *string
 	RecvType string
+	// TypeArgs are the names the receiver gives to the type parameters of a generic type
+	TypeArgs []string
 	isRef    bool
 }
 
-func ParseFunction(decl *ast.FuncDecl) *Function {
+// ParseFunction parses a function or method declaration, located by source.
+func ParseFunction(source *Source, decl *ast.FuncDecl) (*Function, error) {
 	function := new(Function)
 
 	function.Name = decl.Name.Name
-	// TODO: Support function body parsing
-	function.Body = "unsupported yet"
+	function.nameIdent = decl.Name
+	function.Position = source.Position(decl.Pos())
+	if decl.Body != nil {
+		function.Body = source.Text(decl.Body)
+		function.BodyNode = decl.Body
+	}
 	if decl.Doc != nil {
 		docString := strings.TrimSpace(decl.Doc.Text())
 		function.Doc = &docString
+		function.Directives = parseDirectives(decl.Doc)
 	}
 
 	if decl.Recv != nil {
-		if len(decl.Recv.List) > 1 {
-			panic(fmt.Sprintf("found multiple receivers for function \"%v\": %+v", function.Name, decl.Recv.List))
+		if len(decl.Recv.List) != 1 || len(decl.Recv.List[0].Names) > 1 {
+			return nil, newParseError(source, decl.Recv, "method %s must have exactly one receiver", function.Name)
+		}
+
+		var name *string
+		if len(decl.Recv.List[0].Names) == 1 {
+			name = &decl.Recv.List[0].Names[0].Name
+		}
+
+		recvType, isRef := decl.Recv.List[0].Type, false
+		if star, ok := recvType.(*ast.StarExpr); ok {
+			recvType, isRef = star.X, true
 		}
-		if len(decl.Recv.List[0].Names) != 1 {
-			panic(fmt.Sprintf("expected one name for function's \"%v\" reciever, found: %+v", function.Name, decl.Recv.List[0].Names))
+
+		typeName, typeArgs, ok := parseReceiverType(recvType)
+		if !ok {
+			return nil, newParseError(source, decl.Recv, "unsupported receiver type of method %s", function.Name)
+		}
+		function.Receiver = &FunctionReceiver{Name: name, isRef: isRef, RecvType: typeName, TypeArgs: typeArgs}
+	}
+
+	function.TypeParams = ParseTypeParams(decl.Type.TypeParams)
+	if err := parseFunctionType(source, function, decl.Type); err != nil {
+		return nil, err
+	}
+	return function, nil
+}
+
+// parseReceiverType returns the name of the receiver type, and the names it gives to the type parameters of a generic
+// receiver, e.g. T in "func (r *Repo[T])".
+func parseReceiverType(expr ast.Expr) (string, []string, bool) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.String(), nil, true
+	case *ast.IndexExpr:
+		name, _, ok := parseReceiverType(expr.X)
+		return name, []string{ParseTypeExpr(expr.Index).String()}, ok
+	case *ast.IndexListExpr:
+		name, _, ok := parseReceiverType(expr.X)
+		return name, utils.Map(slices.Values(expr.Indices), func(index ast.Expr) string { return ParseTypeExpr(index).String() }), ok
+	default:
+		return "", nil, false
+	}
+}
+
+// parseDirectives returns the directive comments (e.g. "//goop:vtable name") of doc, without their leading slashes.
+func parseDirectives(doc *ast.CommentGroup) []string {
+	directives := []string{}
+	for _, comment := range doc.List {
+		text, isLineComment := strings.CutPrefix(comment.Text, "//")
+		if !isLineComment {
+			continue
 		}
-		name := decl.Recv.List[0].Names[0].String()
-		switch recvType := decl.Recv.List[0].Type.(type) {
-		case *ast.StarExpr:
-			recvTypeIdent, ok := recvType.X.(*ast.Ident)
-			if !ok {
-				panic(fmt.Sprintf("expected star receiver for function's \"%v\" type, found: %+v", function.Name, recvType))
-			}
-			function.Receiver = &FunctionReceiver{Name: &name, isRef: true, RecvType: recvTypeIdent.String()}
-		case *ast.Ident:
-			function.Receiver = &FunctionReceiver{Name: &name, isRef: false, RecvType: recvType.String()}
-		default:
-			fmt.Printf("unsupported receiver type: %T\n", decl.Recv.List[0].Type)
+
+		// Directives have no space after the slashes, and a colon after their namespace, e.g. "go:generate"
+		namespace, _, hasColon := strings.Cut(text, ":")
+		if hasColon && namespace != "" && !strings.ContainsAny(namespace, " \t") {
+			directives = append(directives, text)
 		}
 	}
 
-	if decl.Type.TypeParams != nil {
-		fmt.Printf("type parameters are not supported, from function \"%v\": %+v", function.Name, decl.Type.TypeParams)
+	return directives
+}
+
+// parseFunctionType fills the arguments and return types of function from funcType.
+func parseFunctionType(source *Source, function *Function, funcType *ast.FuncType) error {
+	var errs ErrorList
+	params, err := parseFields(source, funcType.Params)
+	errs.Add(err)
+	results, err := parseFields(source, funcType.Results)
+	errs.Add(err)
+
+	function.ArgumentTypes = append(function.ArgumentTypes, params...)
+	function.ReturnTypes = append(function.ReturnTypes, results...)
+	return errs.Err()
+}
+
+// parseFields parses the fields of a struct or the parameters of a function, fields may be nil.
+func parseFields(source *Source, fields *ast.FieldList) ([]*FieldDeclaration, error) {
+	var errs ErrorList
+	declarations := []*FieldDeclaration{}
+	if fields == nil {
+		return declarations, nil
 	}
 
-	for _, arg := range decl.Type.Params.List {
-		function.ArgumentTypes = append(function.ArgumentTypes, ParseFieldDeclaration(arg))
+	for _, field := range fields.List {
+		declaration, err := ParseFieldDeclaration(source, field)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		declarations = append(declarations, declaration)
 	}
+	return declarations, errs.Err()
+}
 
-	if decl.Type.Results != nil {
-		for _, arg := range decl.Type.Results.List {
-			function.ReturnTypes = append(function.ReturnTypes, ParseFieldDeclaration(arg))
+// MapTypes returns a copy of the function whose parameter and result types are rewritten by mapping.
+// The resolved types of the copy are dropped when mapping changes any of them, as they no longer describe it.
+func (f *Function) MapTypes(mapping func(string) string) *Function {
+	changed := false
+	mapField := func(field *FieldDeclaration) *FieldDeclaration {
+		mapped := *field
+		mapped.VarType = mapping(field.VarType)
+		if mapped.VarType == field.VarType {
+			return &mapped
 		}
+
+		changed = true
+		mapped.ResolvedType = nil
+		if mappedType, err := ParseTypeString(mapped.VarType); err == nil {
+			mapped.Type = mappedType
+		}
+		return &mapped
+	}
+
+	mapped := *f
+	mapped.ArgumentTypes = utils.Map(slices.Values(f.ArgumentTypes), mapField)
+	mapped.ReturnTypes = utils.Map(slices.Values(f.ReturnTypes), mapField)
+	if changed {
+		mapped.Object = nil
+	}
+	return &mapped
+}
+
+// ResolvedSignature is the type-checked signature of the function without its receiver, so that the signatures of
+// methods of different types can be compared with types.Identical. It's nil unless the package was type checked.
+func (f *Function) ResolvedSignature() *types.Signature {
+	if f.Object == nil {
+		return nil
 	}
 
-	return function
+	signature := f.Object.Type().(*types.Signature)
+	return types.NewSignatureType(nil, nil, nil, signature.Params(), signature.Results(), signature.Variadic())
 }
 
 func (f *Function) Declaration() string {
@@ -79,21 +197,15 @@ func (f *Function) Declaration() string {
 		sb.WriteString(fmt.Sprintf("(%s) ", f.Receiver))
 	}
 
-	sb.WriteString(fmt.Sprintf("%s(%v)", f.Name, strings.Join(utils.Map(slices.Values(f.ArgumentTypes), (*FieldDeclaration).String), ", ")))
-	if f.ReturnTypes != nil {
-		sb.WriteString(fmt.Sprintf(" (%s)", strings.Join(utils.Map(slices.Values(f.ReturnTypes), (*FieldDeclaration).String), ", ")))
-	}
-
+	sb.WriteString(f.Name)
+	sb.WriteString(TypeParamsString(f.TypeParams))
+	sb.WriteString(signatureString(f.ArgumentTypes, f.ReturnTypes))
 	return sb.String()
 }
 
+// Signature is the type of the function, e.g. "func(a int) error".
 func (f *Function) Signature() string {
-	parameters := strings.Join(utils.Map(slices.Values(f.ArgumentTypes), (*FieldDeclaration).String), ", ")
-	returns := ""
-	if f.ReturnTypes != nil {
-		returns = fmt.Sprintf(" (%s)", strings.Join(utils.Map(slices.Values(f.ReturnTypes), (*FieldDeclaration).String), ", "))
-	}
-	return fmt.Sprintf("func (%s)%s", parameters, returns)
+	return "func" + signatureString(f.ArgumentTypes, f.ReturnTypes)
 }
 
 func (f *Function) String() string {
@@ -106,11 +218,11 @@ func (f *Function) String() string {
 
 	sb.WriteString("func ")
 	sb.WriteString(f.Declaration())
-	sb.WriteString(" {\n")
-
-	sb.WriteString("\t<")
-	sb.WriteString(f.Body)
-	sb.WriteString(">\n}\n")
+	if f.Body != "" {
+		sb.WriteString(" ")
+		sb.WriteString(f.Body)
+	}
+	sb.WriteString("\n")
 	return sb.String()
 }
 
@@ -124,5 +236,8 @@ func (r *FunctionReceiver) String() string {
 		sb.WriteString("*")
 	}
 	sb.WriteString(r.RecvType)
+	if len(r.TypeArgs) > 0 {
+		sb.WriteString(fmt.Sprintf("[%s]", strings.Join(r.TypeArgs, ", ")))
+	}
 	return sb.String()
 }
@@ -0,0 +1,63 @@
+package package_parser
+
+import (
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// GeneratedFiles are the generated files ParsePackage skips.
+type GeneratedFiles int
+
+const (
+	// ParseGenerated parses generated files like any other file
+	ParseGenerated GeneratedFiles = iota
+	// SkipGenerated skips every generated file
+	SkipGenerated
+	// SkipGoopGenerated only skips the files generated by goop, keeping other generated code (e.g. protobuf stubs)
+	SkipGoopGenerated
+)
+
+// generatedComment is the comment marking generated files, see https://go.dev/s/generatedcode.
+var generatedComment = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// GeneratedBy returns the "// Code generated ... DO NOT EDIT." line of the file at path, which may appear anywhere
+// before its package clause, or an empty string if the file isn't generated.
+func GeneratedBy(path string) (string, error) {
+	// The syntax errors of the file are ignored, they're reported when parsing it
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if f == nil {
+		return "", err
+	}
+
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+
+		for _, comment := range group.List {
+			if generatedComment.MatchString(comment.Text) {
+				return comment.Text, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// IsGoopGenerated reports whether a file marked generated by the generated line was generated by goop.
+func IsGoopGenerated(path string, generatedLine string) bool {
+	return strings.HasPrefix(generatedLine, "// Code generated by goop") ||
+		strings.HasSuffix(path, "_goop.go") || strings.HasSuffix(path, "_goop_test.go")
+}
+
+// skips reports whether the file at path is one of the generated files to skip.
+func (g GeneratedFiles) skips(path string) (bool, error) {
+	generatedLine, err := GeneratedBy(path)
+	if err != nil || generatedLine == "" {
+		return false, err
+	}
+
+	return g == SkipGenerated || g == SkipGoopGenerated && IsGoopGenerated(path, generatedLine), nil
+}
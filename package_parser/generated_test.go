@@ -0,0 +1,129 @@
+package package_parser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFiles writes the files to a temporary directory, returning it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestGeneratedBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "goop",
+			source:   "// Code generated by goop; DO NOT EDIT.\npackage p\n",
+			expected: "// Code generated by goop; DO NOT EDIT.",
+		},
+		{
+			name:     "other generator",
+			source:   "// Code generated by protoc-gen-go. DO NOT EDIT.\n// source: a.proto\n\npackage p\n",
+			expected: "// Code generated by protoc-gen-go. DO NOT EDIT.",
+		},
+		{
+			name:     "after a build constraint",
+			source:   "//go:build linux\n\n// Code generated by stringer. DO NOT EDIT.\n\npackage p\n",
+			expected: "// Code generated by stringer. DO NOT EDIT.",
+		},
+		{
+			name:     "syntax errors after the package clause",
+			source:   "// Code generated by goop; DO NOT EDIT.\npackage p\n\nfunc {\n",
+			expected: "// Code generated by goop; DO NOT EDIT.",
+		},
+		{name: "not generated", source: "package p\n"},
+		{name: "after the package clause", source: "package p\n\n// Code generated by goop; DO NOT EDIT.\n"},
+		{name: "lowercase", source: "// Code generated by goop; do not edit.\npackage p\n"},
+		{name: "missing period", source: "// Code generated by goop; DO NOT EDIT\npackage p\n"},
+		{name: "not the whole line", source: "// Code generated by goop; DO NOT EDIT. Really.\npackage p\n"},
+		{name: "block comment", source: "/* Code generated by goop; DO NOT EDIT. */\npackage p\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"file.go": test.source})
+			generatedLine, err := GeneratedBy(filepath.Join(dir, "file.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if generatedLine != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, generatedLine)
+			}
+		})
+	}
+
+	if _, err := GeneratedBy(filepath.Join(t.TempDir(), "missing.go")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestIsGoopGenerated(t *testing.T) {
+	tests := []struct {
+		path          string
+		generatedLine string
+		expected      bool
+	}{
+		{path: "a.go", generatedLine: "// Code generated by goop; DO NOT EDIT.", expected: true},
+		{path: "a_goop.go", generatedLine: "// Code generated by goop; DO NOT EDIT.", expected: true},
+		{path: "a_goop.go", generatedLine: "// Code generated by an older tool. DO NOT EDIT.", expected: true},
+		{path: "a_goop_test.go", generatedLine: "// Code generated by an older tool. DO NOT EDIT.", expected: true},
+		{path: "a.pb.go", generatedLine: "// Code generated by protoc-gen-go. DO NOT EDIT.", expected: false},
+		{path: "goop.go", generatedLine: "// Code generated by stringer. DO NOT EDIT.", expected: false},
+		{path: "a_goop.go.orig", generatedLine: "// Code generated by stringer. DO NOT EDIT.", expected: false},
+	}
+
+	for _, test := range tests {
+		if IsGoopGenerated(test.path, test.generatedLine) != test.expected {
+			t.Errorf("expected IsGoopGenerated(%q, %q) to be %v", test.path, test.generatedLine, test.expected)
+		}
+	}
+}
+
+func TestParsePackageGenerated(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.go":      "package p\n\ntype A struct{}\n",
+		"a_goop.go": "// Code generated by goop; DO NOT EDIT.\npackage p\n\ntype B struct{}\n",
+		"c.pb.go":   "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage p\n\ntype C struct{}\n",
+	})
+
+	tests := []struct {
+		generated GeneratedFiles
+		expected  []string
+	}{
+		{generated: ParseGenerated, expected: []string{"A", "B", "C"}},
+		{generated: SkipGenerated, expected: []string{"A"}},
+		{generated: SkipGoopGenerated, expected: []string{"A", "C"}},
+	}
+
+	for _, test := range tests {
+		pack, err := ParsePackage("p", dir, ParseOptions{Generated: test.generated})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		structs := []string{}
+		for _, st := range pack.GetStructs() {
+			structs = append(structs, st.Name)
+		}
+		slices.Sort(structs)
+		if !slices.Equal(structs, test.expected) {
+			t.Errorf("generated files %d: expected the structs %v, got %v", test.generated, test.expected, structs)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package package_parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/build"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// ListedPackage is the description of a package reported by "go list".
+type ListedPackage struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	// Error is set when the package failed to load, Pos being set for errors in its source
+	Error *struct {
+		Pos string
+		Err string
+	}
+}
+
+// Err is the error loading the package, if any.
+func (p *ListedPackage) Err() error {
+	if p.Error == nil {
+		return nil
+	}
+
+	return fmt.Errorf("go list %s: %s", p.ImportPath, p.Error.Err)
+}
+
+// ListPackages runs "go list" from dir, describing the packages matching the patterns (import paths, relative
+// directories or "..." patterns) as resolved by the module of dir, with the build tags of build.Default. Packages failing on errors in their source are
+// still listed, so that parsing them reports the errors with their positions, see ListedPackage.Err.
+func ListPackages(dir string, patterns ...string) ([]*ListedPackage, error) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"list", "-e", "-json=ImportPath,Name,Dir,Error"}
+	if len(build.Default.BuildTags) > 0 {
+		args = append(args, "-tags="+strings.Join(build.Default.BuildTags, ","))
+	}
+	cmd := exec.Command("go", append(append(args, "--"), patterns...)...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list %s: %w: %s", strings.Join(patterns, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	packages := []*ListedPackage{}
+	decoder := json.NewDecoder(&stdout)
+	for {
+		listed := new(ListedPackage)
+		if err := decoder.Decode(listed); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("go list %s: %w", strings.Join(patterns, " "), err)
+		}
+
+		if listed.Error != nil && (listed.Error.Pos == "" || listed.Dir == "") {
+			return nil, listed.Err()
+		}
+
+		packages = append(packages, listed)
+	}
+
+	return packages, nil
+}
@@ -3,6 +3,7 @@ package package_parser
 import (
 	"fmt"
 	"go/ast"
+	"strconv"
 )
 
 type Import struct {
@@ -18,6 +19,25 @@ func ParseImport(imp *ast.ImportSpec) *Import {
 	}
 }
 
+// Alias returns the name the import is renamed to, or an empty string if it isn't renamed.
+func (i *Import) Alias() string {
+	if i.alias == nil {
+		return ""
+	}
+
+	return *i.alias
+}
+
+// Path returns the unquoted import path.
+func (i *Import) Path() string {
+	path, err := strconv.Unquote(i.path)
+	if err != nil {
+		return i.path
+	}
+
+	return path
+}
+
 func (i *Import) String() string {
 	if i.alias != nil {
 		return fmt.Sprintf("%v %v", *i.alias, i.path)
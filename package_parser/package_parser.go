@@ -3,7 +3,9 @@ package package_parser
 import (
 	"fmt"
 	"github.com/tadnir/goop/utils"
-	"log"
+	"go/build"
+	"go/token"
+	"go/types"
 	"maps"
 	"os"
 	"path/filepath"
@@ -13,74 +15,141 @@ import (
 
 type GoPackage struct {
 	packageName  string
+	packagePath  string
 	packageFiles map[string]*GoFile
+	// types is the type-checked package, nil unless parsed with ParseOptions.TypeCheck
+	types *types.Package
+	// typeErrors are the errors found while type checking, which don't fail the parsing
+	typeErrors []error
+	// externalTests is the "<name>_test" package of the directory's test files, nil unless parsed with
+	// ParseOptions.IncludeTests
+	externalTests *GoPackage
 }
 
-func isFileGenerated(path string) (bool, error) {
-	myFile, err := os.Open(path)
-	if err != nil {
-		log.Fatalf("impossible to open file: %s", err)
-	}
-
-	defer myFile.Close()
-	generatedString := "// Code generated"
-	buf := make([]byte, len(generatedString))
-	n, err := myFile.Read(buf)
-	if err != nil {
-		return false, fmt.Errorf("impossible to read file: %s", err)
-	}
-	if n != len(generatedString) {
-		return false, nil
-	}
-
-	return string(buf) == generatedString, nil
+type ParseOptions struct {
+	// Generated decides which generated files are skipped, none by default
+	Generated GeneratedFiles
+	// TypeCheck resolves the types of the declarations using go/types, see GoPackage.Types
+	TypeCheck bool
+	// IncludeTests also parses the _test.go files, the ones of the external test package are parsed to its own
+	// package, see GoPackage.ExternalTests
+	IncludeTests bool
+	// BuildContext decides which files are built according to their build constraints, GOOS, GOARCH and build tags,
+	// build.Default when nil
+	BuildContext *build.Context
 }
 
-func ParsePackage(packageName string, packagePath string, ignoreGenerated bool) (*GoPackage, error) {
-	pack := &GoPackage{packageName: packageName, packageFiles: map[string]*GoFile{}}
+// ParsePackage parses the files of the package in packagePath which are built by the options' build context.
+// The errors of all the files are returned together as an ErrorList.
+func ParsePackage(packageName string, packagePath string, options ParseOptions) (*GoPackage, error) {
+	pack := &GoPackage{packageName: packageName, packagePath: packagePath, packageFiles: map[string]*GoFile{}}
+	externalTests := &GoPackage{packageName: packageName + "_test", packagePath: packagePath, packageFiles: map[string]*GoFile{}}
+	fileSet := token.NewFileSet()
 	entries, err := os.ReadDir(packagePath)
 	if err != nil {
 		return nil, err
 	}
 
+	buildContext := options.BuildContext
+	if buildContext == nil {
+		buildContext = &build.Default
+	}
+
+	var errs ErrorList
 	for _, e := range entries {
 		if !strings.HasSuffix(e.Name(), ".go") || e.IsDir() {
 			continue
 		}
 
-		if ignoreGenerated {
-			isGen, err := isFileGenerated(filepath.Join(packagePath, e.Name()))
+		if strings.HasSuffix(e.Name(), "_test.go") && !options.IncludeTests {
+			continue
+		}
+
+		matches, err := buildContext.MatchFile(packagePath, e.Name())
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		if !matches {
+			utils.Logf(utils.LogVerbose, "Skipping file %s excluded by its build constraints in package \"%s\".\n", e.Name(), packageName)
+			continue
+		}
+
+		if options.Generated != ParseGenerated {
+			skip, err := options.Generated.skips(filepath.Join(packagePath, e.Name()))
 			if err != nil {
-				return nil, err
+				errs.Add(err)
+				continue
 			}
 
-			if isGen {
-				fmt.Printf("Skipping generated file %s in package \"%s\".\n", e.Name(), packageName)
+			if skip {
+				utils.Logf(utils.LogVerbose, "Skipping generated file %s in package \"%s\".\n", e.Name(), packageName)
 				continue
 			}
 		}
 
-		packFile, err := ParseGoFile(packagePath, e.Name())
-		if err != nil {
-			return nil, err
+		packFile, err := parseGoFile(fileSet, packagePath, e.Name())
+		errs.Add(err)
+		if packFile == nil {
+			continue
+		}
+
+		if packFile.packageName == externalTests.packageName && strings.HasSuffix(e.Name(), "_test.go") {
+			externalTests.packageFiles[e.Name()] = packFile
+			continue
 		}
 
 		if packFile.packageName != pack.packageName {
-			return nil, fmt.Errorf("package %s contains multiple packages definitions", packagePath)
+			errs.Add(&ParseError{
+				Position:  fileSet.Position(packFile.syntax.Name.Pos()),
+				Construct: "package " + packFile.packageName,
+				Message:   fmt.Sprintf("package %s contains multiple packages definitions, expected package %s", packagePath, pack.packageName),
+			})
+			continue
 		}
 
 		pack.packageFiles[e.Name()] = packFile
 	}
 
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(externalTests.packageFiles) > 0 {
+		pack.externalTests = externalTests
+	}
+
+	if options.TypeCheck {
+		pack.typeCheck(fileSet)
+		if pack.externalTests != nil {
+			pack.externalTests.typeCheck(fileSet)
+		}
+	}
+
 	return pack, nil
 }
 
+// ExternalTests returns the "<name>_test" package declared by the test files of the package's directory, or nil if
+// there's none or the tests weren't parsed.
+func (pack *GoPackage) ExternalTests() *GoPackage {
+	return pack.externalTests
+}
+
 func (pack *GoPackage) GetName() string {
 	return pack.packageName
 }
 
+// GetPath returns the directory of the package.
+func (pack *GoPackage) GetPath() string {
+	return pack.packagePath
+}
+
+// GetFiles returns the files of the package sorted by their names.
 func (pack *GoPackage) GetFiles() []*GoFile {
-	return slices.Collect(maps.Values(pack.packageFiles))
+	return slices.SortedFunc(maps.Values(pack.packageFiles), func(f1 *GoFile, f2 *GoFile) int {
+		return strings.Compare(f1.fileName, f2.fileName)
+	})
 }
 
 func (pack *GoPackage) GetFile(fileName string) (*GoFile, error) {
@@ -93,11 +162,113 @@ func (pack *GoPackage) GetFile(fileName string) (*GoFile, error) {
 }
 
 func (pack *GoPackage) GetStructs() []*StructDeclaration {
-	return slices.Concat(utils.Map(maps.Values(pack.packageFiles), (*GoFile).GetStructs)...)
+	return slices.Concat(utils.Map(slices.Values(pack.GetFiles()), (*GoFile).GetStructs)...)
+}
+
+// GetStructFile returns the file declaring the struct named name.
+func (pack *GoPackage) GetStructFile(name string) (*GoFile, error) {
+	for _, file := range pack.GetFiles() {
+		if _, ok := file.structs[name]; ok {
+			return file, nil
+		}
+	}
+
+	return nil, fmt.Errorf("struct %s not found in package %s", name, pack.packageName)
+}
+
+func (pack *GoPackage) GetInterfaces() []*InterfaceDeclaration {
+	return slices.Concat(utils.Map(slices.Values(pack.GetFiles()), (*GoFile).GetInterfaces)...)
+}
+
+func (pack *GoPackage) GetInterface(name string) (*InterfaceDeclaration, error) {
+	for _, file := range pack.packageFiles {
+		if in, ok := file.interfaces[name]; ok {
+			return in, nil
+		}
+	}
+
+	return nil, fmt.Errorf("interface %s not found in package %s", name, pack.packageName)
+}
+
+// GetMethodSet returns the methods of the interface named name, including the ones of the interfaces it embeds, which
+// must be declared by the package. Type set elements (e.g. ~int) don't add methods.
+func (pack *GoPackage) GetMethodSet(name string) ([]*Function, error) {
+	in, err := pack.GetInterface(name)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := slices.Clone(in.Methods)
+	for _, embedded := range in.Embedded {
+		embeddedName, typeArgs := embedded, []TypeExpr{}
+		if generic, isGeneric := embedded.(*GenericType); isGeneric {
+			embeddedName, typeArgs = generic.Base, generic.Args
+		}
+
+		switch embeddedName := embeddedName.(type) {
+		case *IdentType:
+			embeddedMethods, err := pack.GetMethodSet(embeddedName.Name)
+			if err != nil {
+				return nil, fmt.Errorf("interface %s embeds %s: %w", name, embedded, err)
+			}
+
+			embeddedInterface, _ := pack.GetInterface(embeddedName.Name)
+			for _, method := range embeddedMethods {
+				methods = append(methods, instantiateMethod(method, embeddedInterface.TypeParams, typeArgs))
+			}
+		case *SelectorType:
+			return nil, fmt.Errorf("interface %s embeds %s, which is declared in another package", name, embedded)
+		}
+	}
+
+	return methods, nil
+}
+
+// instantiateMethod returns a copy of method whose types use typeArgs instead of typeParams.
+func instantiateMethod(method *Function, typeParams []*TypeParam, typeArgs []TypeExpr) *Function {
+	if len(typeArgs) == 0 {
+		return method
+	}
+
+	replacements := map[string]string{}
+	for i, param := range typeParams {
+		if i < len(typeArgs) {
+			replacements[param.Name] = typeArgs[i].String()
+		}
+	}
+
+	return method.MapTypes(func(typeExpr string) string {
+		return utils.ReplaceIdentifiers(typeExpr, replacements)
+	})
+}
+
+func (pack *GoPackage) GetNamedTypes() []*NamedTypeDeclaration {
+	return slices.Concat(utils.Map(slices.Values(pack.GetFiles()), (*GoFile).GetNamedTypes)...)
+}
+
+func (pack *GoPackage) GetVariables() []*ValueDeclaration {
+	return slices.Concat(utils.Map(slices.Values(pack.GetFiles()), (*GoFile).GetVariables)...)
+}
+
+func (pack *GoPackage) GetConstants() []*ValueDeclaration {
+	return slices.Concat(utils.Map(slices.Values(pack.GetFiles()), (*GoFile).GetConstants)...)
+}
+
+// GetValue returns the declaration of the package level variable or constant named name.
+func (pack *GoPackage) GetValue(name string) (*ValueDeclaration, error) {
+	for _, file := range pack.GetFiles() {
+		for _, value := range file.values {
+			if slices.Contains(value.Names, name) {
+				return value, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("value %s not found in package %s", name, pack.packageName)
 }
 
 func (pack *GoPackage) GetFunctions() []*Function {
-	return slices.Concat(utils.Map(maps.Values(pack.packageFiles), (*GoFile).GetFunctions)...)
+	return slices.Concat(utils.Map(slices.Values(pack.GetFiles()), (*GoFile).GetFunctions)...)
 }
 
 func (pack *GoPackage) GetReceiverFunctions(recieverName string) []*Function {
@@ -0,0 +1,45 @@
+package package_parser
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// Source is a parsed file's source, which locates the nodes parsed from it.
+// A nil Source locates nothing, e.g. for type expressions parsed from strings.
+type Source struct {
+	fileSet *token.FileSet
+	src     []byte
+}
+
+func NewSource(fileSet *token.FileSet, src []byte) *Source {
+	return &Source{fileSet: fileSet, src: src}
+}
+
+// Position returns the file, line and column of pos, or the zero position if it's unknown.
+func (s *Source) Position(pos token.Pos) token.Position {
+	if s == nil || !pos.IsValid() {
+		return token.Position{}
+	}
+
+	return s.fileSet.Position(pos)
+}
+
+// Text returns the source text of node, e.g. a function's body including its braces.
+func (s *Source) Text(node ast.Node) string {
+	if s == nil {
+		return ""
+	}
+
+	file := s.fileSet.File(node.Pos())
+	if file == nil {
+		return ""
+	}
+
+	start, end := file.Offset(node.Pos()), file.Offset(node.End())
+	if start < 0 || end > len(s.src) || start > end {
+		return ""
+	}
+
+	return string(s.src[start:end])
+}
@@ -0,0 +1,84 @@
+package package_parser
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+)
+
+// typeCheck resolves the types of the package's declarations with go/types, importing its dependencies from their
+// local sources. Type errors don't stop the checking, since the package usually refers to code goop didn't generate
+// yet, the declarations which can't be resolved are annotated with invalid types.
+func (pack *GoPackage) typeCheck(fileSet *token.FileSet) {
+	files := []*ast.File{}
+	for _, file := range pack.GetFiles() {
+		files = append(files, file.syntax)
+	}
+
+	config := types.Config{
+		Importer: importer.ForCompiler(fileSet, "source", nil),
+		Error: func(err error) {
+			pack.typeErrors = append(pack.typeErrors, err)
+		},
+	}
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}, Types: map[ast.Expr]types.TypeAndValue{}}
+	pack.types, _ = config.Check(pack.packageName, fileSet, files, info)
+
+	for _, file := range pack.GetFiles() {
+		for _, function := range file.functions {
+			resolveFunction(info, function)
+		}
+		for _, st := range file.structs {
+			st.Object, _ = info.Defs[st.nameIdent].(*types.TypeName)
+			for _, field := range st.Variables {
+				resolveField(info, field)
+			}
+		}
+		for _, in := range file.interfaces {
+			in.Object, _ = info.Defs[in.nameIdent].(*types.TypeName)
+			for _, method := range in.Methods {
+				resolveFunction(info, method)
+			}
+		}
+		for _, namedType := range file.namedTypes {
+			namedType.Object, _ = info.Defs[namedType.nameIdent].(*types.TypeName)
+		}
+	}
+}
+
+func resolveFunction(info *types.Info, function *Function) {
+	function.Object, _ = info.Defs[function.nameIdent].(*types.Func)
+	for _, field := range function.ArgumentTypes {
+		resolveField(info, field)
+	}
+	for _, field := range function.ReturnTypes {
+		resolveField(info, field)
+	}
+}
+
+func resolveField(info *types.Info, field *FieldDeclaration) {
+	if field.node == nil {
+		return
+	}
+
+	// The type of a variadic parameter isn't recorded, it's a slice of its elements
+	if ellipsis, isVariadic := field.node.Type.(*ast.Ellipsis); isVariadic {
+		if elem := info.TypeOf(ellipsis.Elt); elem != nil {
+			field.ResolvedType = types.NewSlice(elem)
+		}
+		return
+	}
+
+	field.ResolvedType = info.TypeOf(field.node.Type)
+}
+
+// Types returns the type-checked package, or nil unless it was parsed with ParseOptions.TypeCheck.
+func (pack *GoPackage) Types() *types.Package {
+	return pack.types
+}
+
+// TypeErrors returns the errors found while type checking the package.
+func (pack *GoPackage) TypeErrors() []error {
+	return pack.typeErrors
+}
@@ -0,0 +1,308 @@
+package package_parser
+
+import (
+	"fmt"
+	"github.com/tadnir/goop/utils"
+	"go/ast"
+	"go/parser"
+	"slices"
+	"strings"
+)
+
+// TypeExpr is a parsed Go type expression, its String method formats it back as Go source.
+type TypeExpr interface {
+	String() string
+}
+
+// IdentType is a type named by an identifier, e.g. int or Base.
+type IdentType struct {
+	Name string
+}
+
+// SelectorType is a type of another package, e.g. context.Context.
+type SelectorType struct {
+	Package string
+	Name    string
+}
+
+// PointerType is a pointer to Elem, e.g. *Base.
+type PointerType struct {
+	Elem TypeExpr
+}
+
+// ArrayType is an array of Len elements, e.g. [4]int, or a slice when Len is empty, e.g. []int.
+type ArrayType struct {
+	Len  string
+	Elem TypeExpr
+}
+
+// MapType is a map, e.g. map[string]int.
+type MapType struct {
+	Key   TypeExpr
+	Value TypeExpr
+}
+
+// FuncType is a function type, e.g. func(int) error.
+type FuncType struct {
+	Params  []*FieldDeclaration
+	Results []*FieldDeclaration
+}
+
+// ChanDir is the direction of a channel type.
+type ChanDir int
+
+const (
+	ChanBoth ChanDir = iota
+	ChanSend
+	ChanRecv
+)
+
+// ChanType is a channel, e.g. chan int, chan<- int or <-chan int.
+type ChanType struct {
+	Dir  ChanDir
+	Elem TypeExpr
+}
+
+// EllipsisType is the type of a variadic parameter, e.g. ...any.
+type EllipsisType struct {
+	Elem TypeExpr
+}
+
+// StructType is a struct literal type, e.g. struct{ a int }.
+type StructType struct {
+	Fields []*FieldDeclaration
+}
+
+// InterfaceType is an interface literal type, e.g. interface{ Close() error }, or a constraint, e.g. interface{ ~int }.
+type InterfaceType struct {
+	Methods  []*Function
+	Embedded []TypeExpr
+}
+
+// GenericType is an instantiation of a generic type, e.g. Cache[string, int].
+type GenericType struct {
+	Base TypeExpr
+	Args []TypeExpr
+}
+
+// ParenType is a parenthesized type, e.g. the element of chan (<-chan int).
+type ParenType struct {
+	Elem TypeExpr
+}
+
+// UnionType is a union of type constraints, e.g. ~int | string.
+type UnionType struct {
+	Terms []TypeExpr
+}
+
+// TildeType is a constraint on the underlying type, e.g. ~int.
+type TildeType struct {
+	Elem TypeExpr
+}
+
+// BadType is an expression which isn't a type, e.g. the 3 in Repo[3], kept as its source for the compiler to report.
+type BadType struct {
+	Source string
+}
+
+// ParseTypeExpr parses a type expression.
+func ParseTypeExpr(expr ast.Expr) TypeExpr {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return &IdentType{Name: expr.Name}
+	case *ast.SelectorExpr:
+		return &SelectorType{Package: exprSource(expr.X), Name: expr.Sel.Name}
+	case *ast.StarExpr:
+		return &PointerType{Elem: ParseTypeExpr(expr.X)}
+	case *ast.ArrayType:
+		length := ""
+		if expr.Len != nil {
+			length = exprSource(expr.Len)
+		}
+		return &ArrayType{Len: length, Elem: ParseTypeExpr(expr.Elt)}
+	case *ast.MapType:
+		return &MapType{Key: ParseTypeExpr(expr.Key), Value: ParseTypeExpr(expr.Value)}
+	case *ast.FuncType:
+		function := new(Function)
+		if err := parseFunctionType(nil, function, expr); err != nil {
+			return &BadType{Source: exprSource(expr)}
+		}
+		return &FuncType{Params: function.ArgumentTypes, Results: function.ReturnTypes}
+	case *ast.ChanType:
+		dir := ChanBoth
+		switch expr.Dir {
+		case ast.SEND:
+			dir = ChanSend
+		case ast.RECV:
+			dir = ChanRecv
+		}
+		return &ChanType{Dir: dir, Elem: ParseTypeExpr(expr.Value)}
+	case *ast.Ellipsis:
+		return &EllipsisType{Elem: ParseTypeExpr(expr.Elt)}
+	case *ast.StructType:
+		fields, err := parseFields(nil, expr.Fields)
+		if err != nil {
+			return &BadType{Source: exprSource(expr)}
+		}
+		return &StructType{Fields: fields}
+	case *ast.InterfaceType:
+		interfaceType, err := parseInterfaceType(nil, expr)
+		if err != nil {
+			return &BadType{Source: exprSource(expr)}
+		}
+		return interfaceType
+	case *ast.IndexExpr:
+		return &GenericType{Base: ParseTypeExpr(expr.X), Args: []TypeExpr{ParseTypeExpr(expr.Index)}}
+	case *ast.IndexListExpr:
+		return &GenericType{Base: ParseTypeExpr(expr.X), Args: utils.Map(slices.Values(expr.Indices), ParseTypeExpr)}
+	case *ast.ParenExpr:
+		return &ParenType{Elem: ParseTypeExpr(expr.X)}
+	case *ast.BinaryExpr:
+		// Unions are parsed as left associative binary expressions, e.g. (a | b) | c
+		union := &UnionType{}
+		for _, side := range []ast.Expr{expr.X, expr.Y} {
+			if term, isUnion := ParseTypeExpr(side).(*UnionType); isUnion {
+				union.Terms = append(union.Terms, term.Terms...)
+			} else {
+				union.Terms = append(union.Terms, ParseTypeExpr(side))
+			}
+		}
+		return union
+	case *ast.UnaryExpr:
+		return &TildeType{Elem: ParseTypeExpr(expr.X)}
+	default:
+		return &BadType{Source: exprSource(expr)}
+	}
+}
+
+// ParseTypeString parses a type expression from its source, e.g. "map[string]int".
+func ParseTypeString(source string) (TypeExpr, error) {
+	expr, err := parser.ParseExpr(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type expression %q: %w", source, err)
+	}
+
+	return ParseTypeExpr(expr), nil
+}
+
+// parseInterfaceType parses the methods and embedded elements of an interface, locating its methods by source.
+func parseInterfaceType(source *Source, expr *ast.InterfaceType) (*InterfaceType, error) {
+	var errs ErrorList
+	interfaceType := &InterfaceType{}
+	for _, field := range expr.Methods.List {
+		if funcType, isFunc := field.Type.(*ast.FuncType); isFunc && len(field.Names) == 1 {
+			method := &Function{Name: field.Names[0].Name, Position: source.Position(field.Pos()), nameIdent: field.Names[0]}
+			if field.Doc != nil {
+				docString := strings.TrimSpace(field.Doc.Text())
+				method.Doc = &docString
+				method.Directives = parseDirectives(field.Doc)
+			}
+			errs.Add(parseFunctionType(source, method, funcType))
+			interfaceType.Methods = append(interfaceType.Methods, method)
+			continue
+		}
+
+		// Embedded interfaces and type set elements, e.g. io.Reader or ~int | ~string
+		interfaceType.Embedded = append(interfaceType.Embedded, ParseTypeExpr(field.Type))
+	}
+
+	return interfaceType, errs.Err()
+}
+
+func (t *BadType) String() string {
+	return t.Source
+}
+
+func (t *IdentType) String() string {
+	return t.Name
+}
+
+func (t *SelectorType) String() string {
+	return t.Package + "." + t.Name
+}
+
+func (t *PointerType) String() string {
+	return "*" + t.Elem.String()
+}
+
+func (t *ArrayType) String() string {
+	return fmt.Sprintf("[%s]%s", t.Len, t.Elem)
+}
+
+func (t *MapType) String() string {
+	return fmt.Sprintf("map[%s]%s", t.Key, t.Value)
+}
+
+func (t *FuncType) String() string {
+	return "func" + signatureString(t.Params, t.Results)
+}
+
+func (t *ChanType) String() string {
+	switch t.Dir {
+	case ChanSend:
+		return "chan<- " + t.Elem.String()
+	case ChanRecv:
+		return "<-chan " + t.Elem.String()
+	default:
+		return "chan " + t.Elem.String()
+	}
+}
+
+func (t *EllipsisType) String() string {
+	return "..." + t.Elem.String()
+}
+
+func (t *StructType) String() string {
+	if len(t.Fields) == 0 {
+		return "struct{}"
+	}
+
+	fields := utils.Map(slices.Values(t.Fields), func(field *FieldDeclaration) string {
+		if field.Tag == "" {
+			return field.String()
+		}
+		return fmt.Sprintf("%s `%s`", field, field.Tag)
+	})
+	return fmt.Sprintf("struct{ %s }", strings.Join(fields, "; "))
+}
+
+func (t *InterfaceType) String() string {
+	if len(t.Methods) == 0 && len(t.Embedded) == 0 {
+		return "interface{}"
+	}
+
+	elements := utils.Map(slices.Values(t.Embedded), TypeExpr.String)
+	for _, method := range t.Methods {
+		elements = append(elements, method.Name+signatureString(method.ArgumentTypes, method.ReturnTypes))
+	}
+	return fmt.Sprintf("interface{ %s }", strings.Join(elements, "; "))
+}
+
+func (t *GenericType) String() string {
+	return fmt.Sprintf("%s[%s]", t.Base, strings.Join(utils.Map(slices.Values(t.Args), TypeExpr.String), ", "))
+}
+
+func (t *ParenType) String() string {
+	return fmt.Sprintf("(%s)", t.Elem)
+}
+
+func (t *UnionType) String() string {
+	return strings.Join(utils.Map(slices.Values(t.Terms), TypeExpr.String), " | ")
+}
+
+func (t *TildeType) String() string {
+	return "~" + t.Elem.String()
+}
+
+// signatureString formats the parameters and results of a function as in its declaration, e.g. "(a int) error".
+func signatureString(params []*FieldDeclaration, results []*FieldDeclaration) string {
+	signature := fmt.Sprintf("(%s)", strings.Join(utils.Map(slices.Values(params), (*FieldDeclaration).String), ", "))
+	switch {
+	case len(results) == 1 && len(results[0].Names) == 0:
+		signature += " " + results[0].String()
+	case len(results) > 0:
+		signature += fmt.Sprintf(" (%s)", strings.Join(utils.Map(slices.Values(results), (*FieldDeclaration).String), ", "))
+	}
+
+	return signature
+}
@@ -0,0 +1,117 @@
+package package_parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTypeString(t *testing.T) {
+	tests := []struct {
+		source string
+		// expected is the formatted type, the source itself when empty
+		expected string
+		// model is the type of the parsed expression
+		model TypeExpr
+	}{
+		{source: "int", model: &IdentType{}},
+		{source: "context.Context", model: &SelectorType{}},
+		{source: "*Base", model: &PointerType{}},
+		{source: "[]int", model: &ArrayType{}},
+		{source: "[4]int", model: &ArrayType{}},
+		{source: "[0]handlerAbstract", model: &ArrayType{}},
+		{source: "map[string][]*Base", model: &MapType{}},
+		{source: "func()", model: &FuncType{}},
+		{source: "func(a int, b ...string) (int, error)", model: &FuncType{}},
+		{source: "func(a, b int) (n int, err error)", model: &FuncType{}},
+		{source: "func(int) error", model: &FuncType{}},
+		{source: "chan int", model: &ChanType{}},
+		{source: "chan<- int", model: &ChanType{}},
+		{source: "<-chan int", model: &ChanType{}},
+		{source: "chan (<-chan int)", model: &ChanType{}},
+		{source: "struct{}", model: &StructType{}},
+		{source: "struct{ a, b int; Base }", model: &StructType{}},
+		{source: "struct {\n\tname string `json:\"name\"`\n}", expected: "struct{ name string `json:\"name\"` }", model: &StructType{}},
+		{source: "interface{}", model: &InterfaceType{}},
+		{source: "interface{ io.Reader; Close() error }", model: &InterfaceType{}},
+		{source: "interface{ ~int | ~string }", model: &InterfaceType{}},
+		{source: "Cache[string, int]", model: &GenericType{}},
+		{source: "Repo[T]", model: &GenericType{}},
+		{source: "pkg.Repo[map[K]V]", model: &GenericType{}},
+		{source: "~int | string | ~float64", model: &UnionType{}},
+		{source: "~int", model: &TildeType{}},
+		{source: "(int)", model: &ParenType{}},
+		{source: "3", model: &BadType{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			typeExpr, err := ParseTypeString(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if reflect.TypeOf(typeExpr) != reflect.TypeOf(test.model) {
+				t.Errorf("expected a %T, got %T", test.model, typeExpr)
+			}
+
+			expected := test.expected
+			if expected == "" {
+				expected = test.source
+			}
+			if typeExpr.String() != expected {
+				t.Errorf("expected %q, got %q", expected, typeExpr.String())
+			}
+		})
+	}
+}
+
+func TestParseTypeStringModel(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected TypeExpr
+	}{
+		{
+			source:   "[0]handlerAbstract",
+			expected: &ArrayType{Len: "0", Elem: &IdentType{Name: "handlerAbstract"}},
+		},
+		{
+			source:   "map[string]*pkg.Base",
+			expected: &MapType{Key: &IdentType{Name: "string"}, Value: &PointerType{Elem: &SelectorType{Package: "pkg", Name: "Base"}}},
+		},
+		{
+			source:   "<-chan int",
+			expected: &ChanType{Dir: ChanRecv, Elem: &IdentType{Name: "int"}},
+		},
+		{
+			source: "Cache[K, []V]",
+			expected: &GenericType{
+				Base: &IdentType{Name: "Cache"},
+				Args: []TypeExpr{&IdentType{Name: "K"}, &ArrayType{Elem: &IdentType{Name: "V"}}},
+			},
+		},
+		{
+			// Unions are flattened instead of nested as parsed
+			source:   "~int | string | ~float64",
+			expected: &UnionType{Terms: []TypeExpr{&TildeType{Elem: &IdentType{Name: "int"}}, &IdentType{Name: "string"}, &TildeType{Elem: &IdentType{Name: "float64"}}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			typeExpr, err := ParseTypeString(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(typeExpr, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, typeExpr)
+			}
+		})
+	}
+}
+
+func TestParseTypeStringInvalid(t *testing.T) {
+	if _, err := ParseTypeString("map[string"); err == nil {
+		t.Errorf("expected an error parsing an invalid type expression")
+	}
+}
@@ -0,0 +1,56 @@
+package package_parser
+
+import (
+	"fmt"
+	"github.com/tadnir/goop/utils"
+	"go/ast"
+	"slices"
+	"strings"
+)
+
+// TypeParam is a type parameter of a generic type or function, e.g. T in [T any].
+type TypeParam struct {
+	Name       string
+	Constraint string
+}
+
+// ParseTypeParams returns the type parameters declared by fields, which is nil for non-generic declarations.
+func ParseTypeParams(fields *ast.FieldList) []*TypeParam {
+	if fields == nil {
+		return nil
+	}
+
+	params := []*TypeParam{}
+	for _, field := range fields.List {
+		constraint := ParseTypeExpr(field.Type).String()
+		for _, name := range field.Names {
+			params = append(params, &TypeParam{Name: name.Name, Constraint: constraint})
+		}
+	}
+
+	return params
+}
+
+func (p *TypeParam) String() string {
+	return fmt.Sprintf("%s %s", p.Name, p.Constraint)
+}
+
+// TypeParamsString formats the declaration of type parameters, e.g. "[K comparable, V any]", or an empty string when
+// there are none.
+func TypeParamsString(params []*TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(utils.Map(slices.Values(params), (*TypeParam).String), ", "))
+}
+
+// TypeArgsString formats the type parameters as the arguments of an instantiation, e.g. "[K, V]", or an empty string
+// when there are none.
+func TypeArgsString(params []*TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(utils.Map(slices.Values(params), func(p *TypeParam) string { return p.Name }), ", "))
+}
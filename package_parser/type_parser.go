@@ -2,65 +2,133 @@ package package_parser
 
 import (
 	"fmt"
-	"github.com/tadnir/goop/utils"
 	"go/ast"
-	"slices"
+	"go/token"
+	"go/types"
 	"strings"
 )
 
 type StructDeclaration struct {
-	Name      string
-	Doc       *string
-	Variables []*FieldDeclaration
-}
-
-type InterfaceDeclaration struct {
 	Name string
 	Doc  *string
+	// Directives are the directive comments of the struct without their leading slashes, e.g. "goop:extensible"
+	Directives []string
+	TypeParams []*TypeParam
+	Variables  []*FieldDeclaration
+	// Position is where the struct's name is declared
+	Position token.Position
+	// Object is the type-checked struct, nil unless the package was type checked
+	Object *types.TypeName
+	// nameIdent is the identifier declaring the struct, used to find its object when type checking
+	nameIdent *ast.Ident
 }
 
-func ParseTypeDeclaration(decl *ast.GenDecl) (*StructDeclaration, *InterfaceDeclaration) {
-	if len(decl.Specs) != 1 {
-		panic(fmt.Errorf("expected only one type declaration specs got %+v", decl.Specs))
-	}
+type InterfaceDeclaration struct {
+	Name       string
+	Doc        *string
+	TypeParams []*TypeParam
+	Methods    []*Function
+	// Embedded are the embedded interfaces and type set elements of the interface, e.g. io.Reader or ~int | ~string
+	Embedded []TypeExpr
+	// Position is where the interface's name is declared
+	Position token.Position
+	// Object is the type-checked interface, nil unless the package was type checked
+	Object *types.TypeName
+	// nameIdent is the identifier declaring the interface, used to find its object when type checking
+	nameIdent *ast.Ident
+}
 
-	switch expr := decl.Specs[0].(type) {
-	case *ast.TypeSpec:
-		name := expr.Name.String()
+// NamedTypeDeclaration is a declared type which is neither a struct nor an interface, e.g. "type ID string", or an
+// alias of any type, e.g. "type Names = []string".
+type NamedTypeDeclaration struct {
+	Name       string
+	Doc        *string
+	TypeParams []*TypeParam
+	Type       TypeExpr
+	IsAlias    bool
+	// Position is where the type's name is declared
+	Position token.Position
+	// Object is the type-checked type, nil unless the package was type checked
+	Object *types.TypeName
+	// nameIdent is the identifier declaring the type, used to find its object when type checking
+	nameIdent *ast.Ident
+}
 
-		var doc *string = nil
-		if decl.Doc.Text() != "" {
-			d := decl.Doc.Text()
-			doc = &d
+// ParseTypeDeclaration parses every type spec of a type declaration, either a single one or a grouped "type ( ... )"
+// block, to the structs, interfaces and other named types it declares.
+func ParseTypeDeclaration(source *Source, decl *ast.GenDecl) ([]*StructDeclaration, []*InterfaceDeclaration, []*NamedTypeDeclaration, error) {
+	var errs ErrorList
+	structs := []*StructDeclaration{}
+	interfaces := []*InterfaceDeclaration{}
+	namedTypes := []*NamedTypeDeclaration{}
+	for _, spec := range decl.Specs {
+		typeSpec, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			errs.Add(newParseError(source, spec, "unknown type declaration spec %T", spec))
+			continue
 		}
 
-		if expr.TypeParams != nil {
-			fmt.Printf("Type parameters for \"%s\" are not yet supported: %+v\n", name, expr.TypeParams)
+		name := typeSpec.Name.String()
+
+		doc := specDoc(typeSpec.Doc, decl)
+
+		if typeSpec.Assign.IsValid() {
+			namedTypes = append(namedTypes, &NamedTypeDeclaration{
+				Name:       name,
+				Doc:        doc,
+				nameIdent:  typeSpec.Name,
+				TypeParams: ParseTypeParams(typeSpec.TypeParams),
+				Type:       ParseTypeExpr(typeSpec.Type),
+				IsAlias:    true,
+				Position:   source.Position(typeSpec.Name.Pos()),
+			})
+			continue
 		}
 
-		switch typeDecl := expr.Type.(type) {
+		switch typeDecl := typeSpec.Type.(type) {
 		case *ast.InterfaceType:
-			return nil, &InterfaceDeclaration{
-				Name: name,
-				Doc:  doc,
+			interfaceType, err := parseInterfaceType(source, typeDecl)
+			if err != nil {
+				errs.Add(err)
+				continue
 			}
+			interfaces = append(interfaces, &InterfaceDeclaration{
+				Name:       name,
+				Doc:        doc,
+				nameIdent:  typeSpec.Name,
+				TypeParams: ParseTypeParams(typeSpec.TypeParams),
+				Methods:    interfaceType.Methods,
+				Embedded:   interfaceType.Embedded,
+				Position:   source.Position(typeSpec.Name.Pos()),
+			})
 		case *ast.StructType:
-			return &StructDeclaration{
-				Name:      name,
-				Doc:       doc,
-				Variables: utils.Map(slices.Values(typeDecl.Fields.List), ParseFieldDeclaration),
-			}, nil
+			fields, err := parseFields(source, typeDecl.Fields)
+			if err != nil {
+				errs.Add(err)
+				continue
+			}
+			structs = append(structs, &StructDeclaration{
+				Name:       name,
+				Doc:        doc,
+				Directives: specDirectives(typeSpec.Doc, decl),
+				nameIdent:  typeSpec.Name,
+				TypeParams: ParseTypeParams(typeSpec.TypeParams),
+				Variables:  fields,
+				Position:   source.Position(typeSpec.Name.Pos()),
+			})
 		default:
-			panic(fmt.Errorf("expected type declaration to be struct or interface got %T", expr.Type))
+			namedTypes = append(namedTypes, &NamedTypeDeclaration{
+				Name:       name,
+				Doc:        doc,
+				nameIdent:  typeSpec.Name,
+				TypeParams: ParseTypeParams(typeSpec.TypeParams),
+				Type:       ParseTypeExpr(typeSpec.Type),
+				Position:   source.Position(typeSpec.Name.Pos()),
+			})
 		}
-
-	//case *ast.ValueSpec:
-	//	fmt.Printf("Skipping %+v %T\n", expr, expr)
-	//case *ast.ImportSpec:
-	//	fmt.Printf("Skipping %+v\n", expr)
-	default:
-		panic(fmt.Errorf("unknown declaration spec type: %T", expr))
 	}
+
+	return structs, interfaces, namedTypes, errs.Err()
 }
 
 func (s *StructDeclaration) String() string {
@@ -71,7 +139,7 @@ func (s *StructDeclaration) String() string {
 		sb.WriteString("\n")
 	}
 
-	sb.WriteString(fmt.Sprintf("type %v struct {", s.Name))
+	sb.WriteString(fmt.Sprintf("type %v%v struct {", s.Name, TypeParamsString(s.TypeParams)))
 	if len(s.Variables) > 0 {
 		sb.WriteString("\n")
 	}
@@ -90,7 +158,32 @@ func (s *InterfaceDeclaration) String() string {
 		sb.WriteString("\n")
 	}
 
-	sb.WriteString(fmt.Sprintf("type %v interface {", s.Name))
+	sb.WriteString(fmt.Sprintf("type %v%v interface {", s.Name, TypeParamsString(s.TypeParams)))
+	if len(s.Embedded) > 0 || len(s.Methods) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, embedded := range s.Embedded {
+		sb.WriteString(fmt.Sprintf("\t%v\n", embedded))
+	}
+	for _, method := range s.Methods {
+		sb.WriteString(fmt.Sprintf("\t%v\n", method.Declaration()))
+	}
 	sb.WriteString("}\n")
 	return sb.String()
 }
+
+func (t *NamedTypeDeclaration) String() string {
+	var sb strings.Builder
+	if t.Doc != nil {
+		sb.WriteString("// ")
+		sb.WriteString(*t.Doc)
+		sb.WriteString("\n")
+	}
+
+	assign := " "
+	if t.IsAlias {
+		assign = " = "
+	}
+	sb.WriteString(fmt.Sprintf("type %v%v%v%v\n", t.Name, TypeParamsString(t.TypeParams), assign, t.Type))
+	return sb.String()
+}
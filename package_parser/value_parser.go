@@ -0,0 +1,125 @@
+package package_parser
+
+import (
+	"fmt"
+	"github.com/tadnir/goop/utils"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// ValueDeclaration is a package level variable or constant spec, e.g. "var a, b int = 1, 2" or a constant of an iota
+// block.
+type ValueDeclaration struct {
+	Names []string
+	Doc   *string
+	// Type is the declared type, nil when the type is inferred from the values
+	Type TypeExpr
+	// VarType is the formatted Type, empty when the type is inferred
+	VarType string
+	// Values are the source of the values, a constant spec omitting them repeats the previous spec's values and type
+	Values  []string
+	IsConst bool
+	// Iota is the value of iota for the constant, which is the index of its spec in the declaration
+	Iota int
+}
+
+// ParseValueDeclaration parses every spec of a var or const declaration, either a single one or a grouped block.
+func ParseValueDeclaration(source *Source, decl *ast.GenDecl) ([]*ValueDeclaration, error) {
+	var errs ErrorList
+	values := []*ValueDeclaration{}
+	var previous *ValueDeclaration
+	for i, spec := range decl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			errs.Add(newParseError(source, spec, "unknown value declaration spec %T", spec))
+			continue
+		}
+
+		value := &ValueDeclaration{
+			Names:   utils.Map(slices.Values(valueSpec.Names), (*ast.Ident).String),
+			Doc:     specDoc(valueSpec.Doc, decl),
+			Values:  utils.Map(slices.Values(valueSpec.Values), exprSource),
+			IsConst: decl.Tok == token.CONST,
+			Iota:    i,
+		}
+		if valueSpec.Type != nil {
+			value.Type = ParseTypeExpr(valueSpec.Type)
+			value.VarType = value.Type.String()
+		}
+
+		// Constants without values implicitly repeat the values and type of the previous spec, e.g. in iota blocks
+		if value.IsConst && value.Type == nil && len(value.Values) == 0 && previous != nil {
+			value.Type, value.VarType, value.Values = previous.Type, previous.VarType, previous.Values
+		}
+
+		values = append(values, value)
+		previous = value
+	}
+
+	return values, errs.Err()
+}
+
+// specDoc returns the doc of a spec, which is attached to the declaration when it isn't in a grouped block.
+func specDoc(doc *ast.CommentGroup, decl *ast.GenDecl) *string {
+	if !decl.Lparen.IsValid() {
+		doc = decl.Doc
+	}
+
+	if doc.Text() == "" {
+		return nil
+	}
+
+	text := doc.Text()
+	return &text
+}
+
+// specDirectives returns the directive comments of a spec, which are the ones of its declaration when it isn't grouped.
+func specDirectives(doc *ast.CommentGroup, decl *ast.GenDecl) []string {
+	if !decl.Lparen.IsValid() {
+		doc = decl.Doc
+	}
+
+	if doc == nil {
+		return []string{}
+	}
+
+	return parseDirectives(doc)
+}
+
+// exprSource formats an expression back to its source.
+func exprSource(expr ast.Expr) string {
+	var sb strings.Builder
+	if err := printer.Fprint(&sb, token.NewFileSet(), expr); err != nil {
+		// The printer only fails on nodes it doesn't know, which are formatted in short instead
+		return types.ExprString(expr)
+	}
+
+	return sb.String()
+}
+
+func (v *ValueDeclaration) String() string {
+	var sb strings.Builder
+	if v.Doc != nil {
+		sb.WriteString("// ")
+		sb.WriteString(*v.Doc)
+		sb.WriteString("\n")
+	}
+
+	keyword := "var"
+	if v.IsConst {
+		keyword = "const"
+	}
+	sb.WriteString(fmt.Sprintf("%s %s", keyword, strings.Join(v.Names, ", ")))
+	if v.VarType != "" {
+		sb.WriteString(" " + v.VarType)
+	}
+	if len(v.Values) > 0 {
+		sb.WriteString(" = " + strings.Join(v.Values, ", "))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
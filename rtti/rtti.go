@@ -0,0 +1,35 @@
+package rtti
+
+// Class describes a goop class at runtime, generated code declares one for every class.
+type Class struct {
+	name  string
+	super *Class
+}
+
+func NewClass(name string, super *Class) *Class {
+	return &Class{name: name, super: super}
+}
+
+func (c *Class) Name() string {
+	return c.name
+}
+
+// Super returns the class c inherits from, or nil if c is the root of its hierarchy.
+func (c *Class) Super() *Class {
+	return c.super
+}
+
+// IsSubclassOf reports whether c is class or inherits from it.
+func (c *Class) IsSubclassOf(class *Class) bool {
+	for ancestor := c; ancestor != nil; ancestor = ancestor.super {
+		if ancestor == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Class) String() string {
+	return c.name
+}
@@ -0,0 +1,8 @@
+package base
+
+//goop:extensible
+type Base struct {
+	baseVtable `goop:"vtable"`
+}
+
+func (b *Base) idImpl() string { return "base" }
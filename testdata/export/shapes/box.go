@@ -0,0 +1,18 @@
+package shapes
+
+type Box[T any] struct {
+	boxVtable[T] `goop:"vtable"`
+	item         T
+}
+
+func (b *Box[T]) GetImpl() T {
+	return b.item
+}
+
+type IntBox struct {
+	Box[int] `goop:"super"`
+}
+
+func (b *IntBox) GetImpl() int {
+	return b.item + 1
+}
@@ -0,0 +1,22 @@
+package shapes
+
+import "example.com/export/base"
+
+type Counter struct {
+	base.Base     `goop:"super"`
+	counterVtable `goop:"vtable,static"`
+	count         int
+}
+
+func (c *Counter) idImpl() string { return "counter" }
+
+func (c *Counter) nextImpl(step int) (int, error) {
+	c.count += step
+	return c.count, nil
+}
+
+func (c *Counter) resetImpl(options struct {
+	Hard bool `json:"hard"`
+}) {
+	c.count = 0
+}
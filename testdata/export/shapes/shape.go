@@ -0,0 +1,20 @@
+package shapes
+
+type Shape struct {
+	shapeVtable `goop:"vtable"`
+	_           [0]shapeAbstract `goop:"abstract"`
+	_           [0]Describer     `goop:"interface"`
+	name        string
+}
+
+type shapeAbstract interface {
+	area() float64
+}
+
+func (s *Shape) construct(name string) {
+	s.name = name
+}
+
+func (s *Shape) DescribeImpl() string {
+	return s.name
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around the changes of a unified diff.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+	// oldLine and newLine are the 0-based lines of the op in the old and new texts
+	oldLine, newLine int
+}
+
+// UnifiedDiff returns the unified diff turning oldText named oldName into newText named newName, or an empty string
+// if they're equal.
+func UnifiedDiff(oldName string, newName string, oldText string, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+	for start := 0; start < len(ops); {
+		// Find the next change, and extend its hunk while the following changes are close enough
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		last := first
+		for next := last + 1; next < len(ops) && next-last <= 2*diffContext; next++ {
+			if ops[next].kind != ' ' {
+				last = next
+			}
+		}
+
+		hunkStart, hunkEnd := max(first-diffContext, 0), min(last+diffContext+1, len(ops))
+		writeHunk(&sb, ops[hunkStart:hunkEnd])
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, ops []diffOp) {
+	oldCount, newCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+
+	// Empty ranges are numbered by the line preceding them
+	oldStart, newStart := ops[0].oldLine+1, ops[0].newLine+1
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits text to its lines, keeping their line endings so that a last line without one differs from the
+// same line with one.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines returns the shortest edit of oldLines to newLines, using their longest common subsequence.
+func diffLines(oldLines []string, newLines []string) []diffOp {
+	// common[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	common := make([][]int, len(oldLines)+1)
+	for i := range common {
+		common[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	ops := []diffOp{}
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			ops = append(ops, diffOp{kind: ' ', line: oldLines[i], oldLine: i, newLine: j})
+			i++
+			j++
+		case j == len(newLines) || i < len(oldLines) && common[i+1][j] >= common[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', line: oldLines[i], oldLine: i, newLine: j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: newLines[j], oldLine: i, newLine: j})
+			j++
+		}
+	}
+
+	return ops
+}
@@ -0,0 +1,81 @@
+package utils
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		// expected is the diff following the file names, empty when the texts are equal
+		expected string
+	}{
+		{
+			name:     "equal",
+			oldText:  "a\nb\n",
+			newText:  "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "both empty",
+			oldText:  "",
+			newText:  "",
+			expected: "",
+		},
+		{
+			name:     "from empty",
+			oldText:  "",
+			newText:  "a\nb\n",
+			expected: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "to empty",
+			oldText:  "a\n",
+			newText:  "",
+			expected: "@@ -1,1 +0,0 @@\n-a\n",
+		},
+		{
+			name:     "insert only",
+			oldText:  "a\nb\nc\n",
+			newText:  "a\nb\nx\nc\n",
+			expected: "@@ -1,3 +1,4 @@\n a\n b\n+x\n c\n",
+		},
+		{
+			name:     "delete only",
+			oldText:  "a\nb\nc\n",
+			newText:  "a\nc\n",
+			expected: "@@ -1,3 +1,2 @@\n a\n-b\n c\n",
+		},
+		{
+			name:     "trailing newline removed",
+			oldText:  "a\nb\n",
+			newText:  "a\nb",
+			expected: "@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name:     "trailing newline added",
+			oldText:  "a",
+			newText:  "a\n",
+			expected: "@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+		{
+			name:     "distant changes",
+			oldText:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			newText:  "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected := test.expected
+			if expected != "" {
+				expected = "--- a\n+++ b\n" + expected
+			}
+
+			if diff := UnifiedDiff("a", "b", test.oldText, test.newText); diff != expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LogLevel is how much goop reports while generating, every level also reports the ones before it.
+type LogLevel int
+
+const (
+	// LogQuiet only reports warnings, errors are returned rather than logged
+	LogQuiet LogLevel = iota
+	// LogNormal reports the packages and the files generated
+	LogNormal
+	// LogVerbose reports the classes found, their goop tags and methods, and the skipped files
+	LogVerbose
+	// LogDebug reports the built classes and the generated sources
+	LogDebug
+)
+
+var logLevelNames = []string{"quiet", "normal", "verbose", "debug"}
+
+var (
+	logLevel            = LogQuiet
+	logOutput io.Writer = os.Stderr
+)
+
+func (l LogLevel) String() string {
+	return logLevelNames[l]
+}
+
+// ParseLogLevel returns the level named name, e.g. "verbose".
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level, levelName := range logLevelNames {
+		if strings.EqualFold(name, levelName) {
+			return LogLevel(level), nil
+		}
+	}
+
+	return LogQuiet, fmt.Errorf("unknown log level %q, expected one of %s", name, strings.Join(logLevelNames, ", "))
+}
+
+func SetLogLevel(level LogLevel) {
+	logLevel = level
+}
+
+func GetLogLevel() LogLevel {
+	return logLevel
+}
+
+// Logf reports a message of the level to stderr, if the current level includes it.
+func Logf(level LogLevel, format string, args ...any) {
+	if level <= logLevel {
+		fmt.Fprintf(logOutput, format, args...)
+	}
+}
+
+// Warnf reports a problem which doesn't stop the generation, at every level.
+func Warnf(format string, args ...any) {
+	Logf(LogQuiet, "warning: "+format, args...)
+}
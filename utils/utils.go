@@ -2,7 +2,10 @@ package utils
 
 import (
 	"iter"
+	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 func Map[T1 interface{}, T2 interface{}](arr iter.Seq[T1], f func(T1) T2) []T2 {
@@ -17,3 +20,21 @@ func Map[T1 interface{}, T2 interface{}](arr iter.Seq[T1], f func(T1) T2) []T2 {
 func Capitalize(str string) string {
 	return strings.ToUpper(string(str[0])) + str[1:]
 }
+
+func IsExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+var identifier = regexp.MustCompile(`\.?[\p{L}_][\p{L}\p{N}_]*`)
+
+// ReplaceIdentifiers replaces the identifiers of a type expression found in replacements, leaving the selected
+// names of qualified identifiers (e.g. Name in pkg.Name) untouched.
+func ReplaceIdentifiers(expr string, replacements map[string]string) string {
+	return identifier.ReplaceAllStringFunc(expr, func(ident string) string {
+		if replacement, ok := replacements[ident]; ok {
+			return replacement
+		}
+		return ident
+	})
+}